@@ -0,0 +1,85 @@
+// Command audit compares the crash points recorded in the games table with
+// the theoretical distribution they were drawn from:
+//
+//	DATABASE_URL=postgres://... audit -from 2024-01-01 -to 2024-02-01 -format json > january.json
+//
+// It exits with status 1 when a goodness-of-fit test fails.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"crash-game/internal/audit"
+	"crash-game/internal/database"
+)
+
+func main() {
+	dsn := flag.String("db", os.Getenv("DATABASE_URL"), "database connection string (default $DATABASE_URL)")
+	distribution := flag.String("distribution", os.Getenv("CRASH_DISTRIBUTION"), "crash distribution spec to audit (default the server's)")
+	from := flag.String("from", "", "first day (or RFC 3339 time) of rounds to audit")
+	to := flag.String("to", "", "day (or RFC 3339 time) the audited rounds end before")
+	alpha := flag.Float64("alpha", audit.DefaultAlpha, "significance level of the goodness-of-fit tests")
+	format := flag.String("format", "text", "output format: text or json")
+	flag.Parse()
+
+	if *dsn == "" {
+		fail(errors.New("-db or DATABASE_URL is required"))
+	}
+	if *format != "text" && *format != "json" {
+		fail(fmt.Errorf("unknown format %q", *format))
+	}
+
+	filter := database.AuditFilter{Distribution: *distribution}
+	var err error
+	if filter.From, err = parseTime(*from); err != nil {
+		fail(fmt.Errorf("-from: %w", err))
+	}
+	if filter.To, err = parseTime(*to); err != nil {
+		fail(fmt.Errorf("-to: %w", err))
+	}
+
+	db, err := database.NewDatabase(*dsn)
+	if err != nil {
+		fail(err)
+	}
+
+	report, err := audit.Run(db, filter, audit.Options{Alpha: *alpha})
+	if err != nil {
+		fail(err)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	if !report.Pass {
+		os.Exit(1)
+	}
+}
+
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "audit: %v\n", err)
+	os.Exit(2)
+}
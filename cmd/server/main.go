@@ -2,8 +2,10 @@ package main
 
 import (
 	"crash-game/internal/database"
+	"crash-game/internal/game"
 	"crash-game/internal/server"
 	"log"
+	"os"
 )
 
 func main() {
@@ -12,7 +14,15 @@ func main() {
 		log.Fatal(err)
 	}
 
-	gameServer := server.NewGameServer(db)
+	dist, err := game.ParseDistribution(os.Getenv("CRASH_DISTRIBUTION"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !game.PlayableDistribution(dist) {
+		log.Fatalf("CRASH_DISTRIBUTION %q is only for tests", dist.Spec())
+	}
+
+	gameServer := server.NewGameServer(db, server.WithDistribution(dist))
 	if err := gameServer.Run(":8080"); err != nil {
 		log.Fatal(err)
 	}
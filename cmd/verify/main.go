@@ -0,0 +1,208 @@
+// Command verify checks crash rounds offline, without calling the game API.
+//
+// Verify a single round from a revealed server seed:
+//
+//	verify -seed <server seed> -round 1234 -client-seed <round client seed>
+//
+// Verify a round hash against a published terminating hash:
+//
+//	verify -hash <round hash> -round 1234 -terminating-hash <hash>
+//
+// Verify an exported game history, or part of it:
+//
+//	verify -history history.json -terminating-hash <hash> -from 1000 -to 2000
+//	verify -history history.json -seed <server seed> -game <game id>
+//
+// A passing round shows that its crash point follows from the committed chain
+// and the recorded client seeds. It does not show that no bettor picked a
+// client seed knowing the round hash: the operator knows the chain in advance
+// and client seeds are only combined when betting closes.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"crash-game/internal/game"
+	"crash-game/internal/models"
+)
+
+type options struct {
+	seed            string
+	seedHash        string
+	chainLength     int64
+	terminatingHash string
+	hash            string
+	round           int64
+	gameID          string
+	clientSeed      string
+	distribution    string
+	history         string
+	serverSeedID    int
+	from            int64
+	to              int64
+	format          string
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.seed, "seed", "", "revealed server seed; rebuilds the whole hash chain")
+	flag.StringVar(&opts.seedHash, "seed-hash", "", "published commitment of the server seed, checked against -seed")
+	flag.Int64Var(&opts.chainLength, "chain-length", game.DefaultChainLength, "length of the chain built from -seed")
+	flag.StringVar(&opts.terminatingHash, "terminating-hash", "", "published terminating hash of the chain")
+	flag.StringVar(&opts.hash, "hash", "", "revealed hash of the round")
+	flag.Int64Var(&opts.round, "round", 0, "round number within the chain")
+	flag.StringVar(&opts.gameID, "game", "", "game ID to look up in -history")
+	flag.StringVar(&opts.clientSeed, "client-seed", "", "round client seed, empty if nobody bet")
+	flag.StringVar(&opts.distribution, "distribution", "", "crash distribution spec for rounds that do not record one (default "+game.DefaultDistributionSpec+")")
+	flag.StringVar(&opts.history, "history", "", "exported game history (JSON array or {\"history\": [...]})")
+	flag.IntVar(&opts.serverSeedID, "server-seed-id", 0, "only check history rounds of this server seed")
+	flag.Int64Var(&opts.from, "from", 0, "first round of -history to check")
+	flag.Int64Var(&opts.to, "to", 0, "last round of -history to check")
+	flag.StringVar(&opts.format, "format", "text", "output format: text or json")
+	flag.Parse()
+
+	report, err := run(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify: %v\n", err)
+		os.Exit(2)
+	}
+
+	if opts.format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify: %v\n", err)
+		os.Exit(2)
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func run(opts options) (*Report, error) {
+	if opts.format != "text" && opts.format != "json" {
+		return nil, fmt.Errorf("unknown format %q", opts.format)
+	}
+	if opts.gameID != "" && opts.history == "" {
+		return nil, errors.New("-game requires -history")
+	}
+
+	defaultDist, err := game.ParseDistribution(opts.distribution)
+	if err != nil {
+		return nil, err
+	}
+
+	v := game.RoundVerifier{Distribution: defaultDist, TerminatingHash: opts.terminatingHash}
+	report := &Report{}
+
+	if opts.seed != "" {
+		if opts.seedHash != "" {
+			report.SeedCommitment = "ok"
+			if game.HashServerSeed(opts.seed) != opts.seedHash {
+				report.SeedCommitment = "mismatch"
+				report.Failed++
+			}
+		}
+
+		v.Chain = game.NewSeedChain(opts.seed, opts.chainLength)
+		if v.TerminatingHash != "" && v.TerminatingHash != v.Chain.TerminatingHash() {
+			return nil, fmt.Errorf("chain of -seed ends in %s, not the given terminating hash", v.Chain.TerminatingHash())
+		}
+		v.TerminatingHash = v.Chain.TerminatingHash()
+	}
+	report.TerminatingHash = v.TerminatingHash
+
+	records, err := selectRecords(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range v.Verify(records) {
+		report.add(result)
+	}
+	return report, nil
+}
+
+// selectRecords returns the rounds to check, either the single round given on
+// the command line or the matching rounds of the history export.
+func selectRecords(opts options) ([]game.RoundRecord, error) {
+	if opts.history == "" {
+		if opts.round < 1 {
+			return nil, errors.New("-round is required")
+		}
+		if opts.hash == "" && opts.seed == "" {
+			return nil, errors.New("either -hash or -seed is required")
+		}
+		return []game.RoundRecord{{Round: opts.round, Hash: opts.hash, ClientSeed: opts.clientSeed}}, nil
+	}
+
+	history, err := loadHistory(opts.history)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []game.RoundRecord
+	seedIDs := make(map[int]bool)
+	for _, h := range history {
+		if opts.gameID != "" && h.GameID != opts.gameID {
+			continue
+		}
+		if opts.round > 0 && h.Round != opts.round {
+			continue
+		}
+		if opts.serverSeedID > 0 && h.ServerSeedID != opts.serverSeedID {
+			continue
+		}
+		if h.Round < opts.from || (opts.to > 0 && h.Round > opts.to) {
+			continue
+		}
+
+		seedIDs[h.ServerSeedID] = true
+		records = append(records, game.RoundRecord{
+			GameID:       h.GameID,
+			Round:        h.Round,
+			Hash:         h.Hash,
+			ClientSeed:   h.ClientSeed,
+			Distribution: h.Distribution,
+			CrashPoint:   h.CrashPoint,
+		})
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("no rounds in the history match")
+	}
+	if len(seedIDs) > 1 {
+		return nil, errors.New("the selected rounds span several server seeds, pick one with -server-seed-id")
+	}
+	return records, nil
+}
+
+func loadHistory(path string) ([]models.GameHistory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []models.GameHistory
+	if err := json.Unmarshal(data, &history); err == nil {
+		return history, nil
+	}
+
+	// Responses of GET /api/game/history wrap the rounds in an object
+	var wrapped struct {
+		History []models.GameHistory `json:"history"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return wrapped.History, nil
+}
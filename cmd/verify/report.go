@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"crash-game/internal/game"
+)
+
+type Report struct {
+	TerminatingHash string            `json:"terminatingHash,omitempty"`
+	SeedCommitment  string            `json:"seedCommitment,omitempty"`
+	Rounds          []game.RoundCheck `json:"rounds"`
+	Passed          int               `json:"passed"`
+	Failed          int               `json:"failed"`
+}
+
+func (r *Report) add(result game.RoundCheck) {
+	r.Rounds = append(r.Rounds, result)
+	if result.Pass {
+		r.Passed++
+	} else {
+		r.Failed++
+	}
+}
+
+func (r *Report) WriteText(w io.Writer) error {
+	if r.TerminatingHash != "" {
+		fmt.Fprintf(w, "terminating hash: %s\n", r.TerminatingHash)
+	}
+	if r.SeedCommitment != "" {
+		fmt.Fprintf(w, "seed commitment:  %s\n", r.SeedCommitment)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROUND\tGAME\tCRASH\tRECORDED\tCHAIN\tRESULT")
+	for _, round := range r.Rounds {
+		recorded := "-"
+		if round.RecordedCrashPoint > 0 {
+			recorded = fmt.Sprintf("%.2f", round.RecordedCrashPoint)
+		}
+		result := "PASS"
+		switch {
+		case round.Chain == game.ChainUnchecked:
+			result = "UNVERIFIED"
+		case !round.Pass:
+			result = "FAIL"
+		}
+		if round.Error != "" {
+			result += " (" + round.Error + ")"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%.2f\t%s\t%s\t%s\n",
+			round.Round, orDash(round.GameID), round.CrashPoint, recorded, round.Chain, result)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d passed, %d failed\n", r.Passed, r.Failed)
+	return err
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
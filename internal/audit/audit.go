@@ -0,0 +1,235 @@
+// Package audit compares recorded crash points with the theoretical
+// distribution they were drawn from.
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"crash-game/internal/game"
+)
+
+const (
+	DefaultAlpha = 0.01
+
+	// minExpected is the smallest expected count of a chi-square cell.
+	// Sparse buckets are merged with their neighbours until they reach it.
+	minExpected = 5
+)
+
+var (
+	// DefaultBuckets are the lower edges of the histogram buckets. The last
+	// bucket is open ended.
+	DefaultBuckets = []float64{1, 1.01, 1.5, 2, 3, 5, 10, 20, 50, 100, 1000}
+
+	// DefaultTargets are the cash out targets the return to player is
+	// reported for.
+	DefaultTargets = []float64{1.5, 2, 5, 10, 100}
+
+	ErrNoRounds = errors.New("no rounds to audit")
+)
+
+type Options struct {
+	Buckets []float64
+	Targets []float64
+	// Alpha is the significance level below which a test fails.
+	Alpha float64
+}
+
+type Bucket struct {
+	Label         string  `json:"label"`
+	Min           float64 `json:"min"`
+	Max           float64 `json:"max,omitempty"` // zero for the open ended last bucket
+	Observed      int     `json:"observed"`
+	Expected      float64 `json:"expected"`
+	ObservedShare float64 `json:"observedShare"`
+	ExpectedShare float64 `json:"expectedShare"`
+}
+
+// TargetRTP is the return of a player who cashes out every round at Target.
+type TargetRTP struct {
+	Target    float64 `json:"target"`
+	Reached   int     `json:"reached"`
+	Empirical float64 `json:"empirical"`
+	Expected  float64 `json:"expected"`
+}
+
+type GoodnessOfFit struct {
+	Statistic        float64 `json:"statistic"`
+	DegreesOfFreedom int     `json:"degreesOfFreedom,omitempty"`
+	PValue           float64 `json:"pValue"`
+	Pass             bool    `json:"pass"`
+}
+
+// Wagers are the amounts actually bet and paid out over the audited rounds.
+type Wagers struct {
+	Wagered float64 `json:"wagered"`
+	Paid    float64 `json:"paid"`
+	RTP     float64 `json:"rtp"`
+}
+
+type Report struct {
+	Distribution string        `json:"distribution"`
+	Rounds       int           `json:"rounds"`
+	Alpha        float64       `json:"alpha"`
+	ExpectedRTP  float64       `json:"expectedRtp"`
+	EmpiricalRTP float64       `json:"empiricalRtp"`
+	Targets      []TargetRTP   `json:"targets"`
+	Wagers       *Wagers       `json:"wagers,omitempty"`
+	Histogram    []Bucket      `json:"histogram"`
+	ChiSquare    GoodnessOfFit `json:"chiSquare"`
+	KS           GoodnessOfFit `json:"ks"`
+	Pass         bool          `json:"pass"`
+}
+
+// Analyze audits crash points against dist. ExpectedRTP and EmpiricalRTP are
+// averaged over the cash out targets.
+func Analyze(crashPoints []float64, dist game.CrashDistribution, opts Options) (*Report, error) {
+	if len(crashPoints) == 0 {
+		return nil, ErrNoRounds
+	}
+	if opts.Buckets == nil {
+		opts.Buckets = DefaultBuckets
+	}
+	if opts.Targets == nil {
+		opts.Targets = DefaultTargets
+	}
+	if opts.Alpha == 0 {
+		opts.Alpha = DefaultAlpha
+	}
+
+	sorted := make([]float64, len(crashPoints))
+	copy(sorted, crashPoints)
+	sort.Float64s(sorted)
+
+	report := &Report{
+		Distribution: dist.Spec(),
+		Rounds:       len(sorted),
+		Alpha:        opts.Alpha,
+		Histogram:    histogram(sorted, dist, opts.Buckets),
+	}
+
+	for _, target := range opts.Targets {
+		reached := len(sorted) - sort.SearchFloat64s(sorted, target)
+		t := TargetRTP{
+			Target:    target,
+			Reached:   reached,
+			Empirical: target * float64(reached) / float64(len(sorted)),
+			Expected:  target * dist.Survival(target),
+		}
+		report.Targets = append(report.Targets, t)
+		report.EmpiricalRTP += t.Empirical / float64(len(opts.Targets))
+		report.ExpectedRTP += t.Expected / float64(len(opts.Targets))
+	}
+
+	report.ChiSquare = chiSquare(report.Histogram, opts.Alpha)
+	report.KS = kolmogorovSmirnov(sorted, dist, opts.Alpha)
+	report.Pass = report.ChiSquare.Pass && report.KS.Pass
+	return report, nil
+}
+
+func histogram(sorted []float64, dist game.CrashDistribution, edges []float64) []Bucket {
+	n := float64(len(sorted))
+	buckets := make([]Bucket, 0, len(edges))
+
+	for i, edge := range edges {
+		b := Bucket{Min: edge}
+		lower := sort.SearchFloat64s(sorted, edge)
+		upper := len(sorted)
+		expected := dist.Survival(edge)
+
+		if i+1 < len(edges) {
+			b.Max = edges[i+1]
+			b.Label = fmt.Sprintf("%gx-%gx", edge, b.Max)
+			upper = sort.SearchFloat64s(sorted, b.Max)
+			expected -= dist.Survival(b.Max)
+		} else {
+			b.Label = fmt.Sprintf("%gx+", edge)
+		}
+
+		b.Observed = upper - lower
+		b.ObservedShare = float64(b.Observed) / n
+		b.ExpectedShare = expected
+		b.Expected = expected * n
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+// chiSquare runs Pearson's test over the histogram, merging buckets whose
+// expected count is too small for the chi-square approximation.
+func chiSquare(buckets []Bucket, alpha float64) GoodnessOfFit {
+	var statistic float64
+	var observed, expected float64
+	cells := 0
+
+	addCell := func(observed, expected float64) {
+		expected = math.Max(expected, 1e-9)
+		statistic += (observed - expected) * (observed - expected) / expected
+		cells++
+	}
+
+	for i, b := range buckets {
+		// Rounds where the distribution allows none are kept in a cell of
+		// their own so merging cannot hide them
+		if b.Expected == 0 {
+			if b.Observed > 0 {
+				addCell(float64(b.Observed), 0)
+			}
+			continue
+		}
+
+		observed += float64(b.Observed)
+		expected += b.Expected
+		remaining := 0.0
+		for _, next := range buckets[i+1:] {
+			remaining += next.Expected
+		}
+		if expected >= minExpected && remaining >= minExpected {
+			addCell(observed, expected)
+			observed, expected = 0, 0
+		}
+	}
+	if observed > 0 || expected > 0 {
+		addCell(observed, expected)
+	}
+
+	fit := GoodnessOfFit{Statistic: statistic, DegreesOfFreedom: cells - 1}
+	fit.PValue = chiSquarePValue(statistic, fit.DegreesOfFreedom)
+	fit.Pass = fit.PValue >= alpha
+	return fit
+}
+
+// kolmogorovSmirnov compares the empirical distribution of the crash points
+// with the theoretical one. Crash points are floored to cents, so the
+// theoretical distribution is evaluated on the same grid; the asymptotic
+// p-value is conservative for such a discrete distribution.
+func kolmogorovSmirnov(sorted []float64, dist game.CrashDistribution, alpha float64) GoodnessOfFit {
+	n := float64(len(sorted))
+	var d float64
+
+	for i := 0; i < len(sorted); {
+		v := sorted[i]
+		j := i
+		for j < len(sorted) && sorted[j] == v {
+			j++
+		}
+
+		// Just below v and at v
+		below := math.Abs(float64(i)/n - (1 - dist.Survival(v)))
+		at := math.Abs(float64(j)/n - (1 - dist.Survival(nextCent(v))))
+		d = math.Max(d, math.Max(below, at))
+		i = j
+	}
+
+	fit := GoodnessOfFit{Statistic: d}
+	fit.PValue = ksPValue(d, len(sorted))
+	fit.Pass = fit.PValue >= alpha
+	return fit
+}
+
+func nextCent(v float64) float64 {
+	return math.Round(v*100+1) / 100
+}
@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"crash-game/internal/database"
+	"crash-game/internal/game"
+)
+
+// Run audits the rounds of the games table selected by filter.
+func Run(db *database.Database, filter database.AuditFilter, opts Options) (*Report, error) {
+	dist, err := game.ParseDistribution(filter.Distribution)
+	if err != nil {
+		return nil, err
+	}
+	filter.Distribution = dist.Spec()
+
+	points, err := db.GetAuditCrashPoints(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := Analyze(points, dist, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	wagered, paid, err := db.GetAuditWagers(filter)
+	if err != nil {
+		return nil, err
+	}
+	if wagered > 0 {
+		report.Wagers = &Wagers{Wagered: wagered, Paid: paid, RTP: paid / wagered}
+	}
+	return report, nil
+}
@@ -0,0 +1,92 @@
+package audit
+
+import "math"
+
+// chiSquarePValue returns P(X >= statistic) for a chi-square distribution
+// with df degrees of freedom.
+func chiSquarePValue(statistic float64, df int) float64 {
+	if df < 1 {
+		return 1
+	}
+	if statistic <= 0 {
+		return 1
+	}
+	return gammaQ(float64(df)/2, statistic/2)
+}
+
+// ksPValue returns the asymptotic probability that the Kolmogorov-Smirnov
+// statistic of n samples exceeds d when the samples follow the theoretical
+// distribution.
+func ksPValue(d float64, n int) float64 {
+	if n == 0 || d <= 0 {
+		return 1
+	}
+	sqrtN := math.Sqrt(float64(n))
+	lambda := (sqrtN + 0.12 + 0.11/sqrtN) * d
+	if lambda < 0.2 {
+		return 1
+	}
+
+	var sum float64
+	sign := 1.0
+	for j := 1; j <= 100; j++ {
+		term := sign * math.Exp(-2*float64(j*j)*lambda*lambda)
+		sum += term
+		if math.Abs(term) < 1e-12 {
+			break
+		}
+		sign = -sign
+	}
+	return math.Max(0, math.Min(1, 2*sum))
+}
+
+// gammaQ is the upper regularized incomplete gamma function Q(a, x).
+func gammaQ(a, x float64) float64 {
+	if x < a+1 {
+		return 1 - gammaSeries(a, x)
+	}
+	return gammaContinuedFraction(a, x)
+}
+
+func gammaSeries(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	sum := 1 / a
+	term := sum
+	for n := 1; n < 500; n++ {
+		term *= x / (a + float64(n))
+		sum += term
+		if math.Abs(term) < math.Abs(sum)*1e-15 {
+			break
+		}
+	}
+	return sum * math.Exp(-x+a*math.Log(x)-lg)
+}
+
+func gammaContinuedFraction(a, x float64) float64 {
+	const tiny = 1e-300
+
+	lg, _ := math.Lgamma(a)
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < 500; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lg) * h
+}
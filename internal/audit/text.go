@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// WriteText prints the report in a form suitable for attaching to audits.
+func (r *Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "distribution: %s\n", r.Distribution)
+	fmt.Fprintf(w, "rounds:       %d\n", r.Rounds)
+	fmt.Fprintf(w, "RTP:          %.4f empirical, %.4f expected\n", r.EmpiricalRTP, r.ExpectedRTP)
+	if r.Wagers != nil {
+		fmt.Fprintf(w, "wagers:       %.2f wagered, %.2f paid, RTP %.4f\n", r.Wagers.Wagered, r.Wagers.Paid, r.Wagers.RTP)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "TARGET\tREACHED\tEMPIRICAL RTP\tEXPECTED RTP\t")
+	for _, t := range r.Targets {
+		fmt.Fprintf(tw, "%gx\t%d\t%.4f\t%.4f\t\n", t.Target, t.Reached, t.Empirical, t.Expected)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "BUCKET\tOBSERVED\tEXPECTED\tOBSERVED %\tEXPECTED %\t")
+	for _, b := range r.Histogram {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%.2f\t%.2f\t\n",
+			b.Label, b.Observed, b.Expected, 100*b.ObservedShare, 100*b.ExpectedShare)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "chi-square: statistic %.3f, df %d, p %.4f %s\n",
+		r.ChiSquare.Statistic, r.ChiSquare.DegreesOfFreedom, r.ChiSquare.PValue, verdict(r.ChiSquare.Pass))
+	fmt.Fprintf(w, "KS:         statistic %.5f, p %.4f %s\n", r.KS.Statistic, r.KS.PValue, verdict(r.KS.Pass))
+	_, err := fmt.Fprintf(w, "result:     %s at alpha %g\n", verdict(r.Pass), r.Alpha)
+	return err
+}
+
+func verdict(pass bool) string {
+	if pass {
+		return "PASS"
+	}
+	return "FAIL"
+}
@@ -20,6 +20,12 @@ type Claims struct {
 	jwt.StandardClaims
 }
 
+type AdminClaims struct {
+	AdminID int    `json:"adminId"`
+	Role    string `json:"role"`
+	jwt.StandardClaims
+}
+
 const secretKey = "your-secret-key" // In production, use environment variable
 
 func GenerateToken(userID string) (string, error) {
@@ -49,3 +55,32 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+func GenerateAdminToken(adminID int, role string) (string, error) {
+	claims := AdminClaims{
+		AdminID: adminID,
+		Role:    role,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(8 * time.Hour).Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secretKey))
+}
+
+func ValidateAdminToken(tokenString string) (*AdminClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &AdminClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secretKey), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*AdminClaims); ok && token.Valid && claims.AdminID != 0 {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
@@ -0,0 +1,302 @@
+package autobet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"crash-game/internal/autobet/script"
+)
+
+var ErrInvalidConfig = errors.New("invalid auto-bet config")
+
+// Strategies set how the stake of the next bet follows from the last round.
+const (
+	Flat           = "flat"            // always the base amount
+	Martingale     = "martingale"      // doubled after a loss, back to base after a win
+	AntiMartingale = "anti_martingale" // doubled after a win, back to base after a loss
+	Custom         = "custom"          // raised by OnWinPercent or OnLossPercent
+	Script         = "script"          // set by the player's script
+)
+
+// Reasons an auto-bet session stopped.
+const (
+	StopRounds       = "rounds"
+	StopProfitTarget = "profit_target"
+	StopLossLimit    = "loss_limit"
+	StopBalanceFloor = "balance_floor"
+	StopByPlayer     = "stopped"
+	StopBetFailed    = "bet_failed"
+	StopByScript     = "script"
+	StopScriptError  = "script_error"
+)
+
+// StopConditions end a session; zero leaves a condition out.
+type StopConditions struct {
+	// Rounds is how many bets are placed.
+	Rounds int `json:"rounds,omitempty"`
+	// ProfitTarget stops once the session won at least this much.
+	ProfitTarget float64 `json:"profitTarget,omitempty"`
+	// LossLimit stops once the session lost at least this much.
+	LossLimit float64 `json:"lossLimit,omitempty"`
+	// BalanceFloor stops before a bet would take the balance below it.
+	BalanceFloor float64 `json:"balanceFloor,omitempty"`
+}
+
+// Config is what a player asks the auto-bet engine to do every round.
+type Config struct {
+	Strategy    string  `json:"strategy"`
+	BaseAmount  float64 `json:"baseAmount"`
+	AutoCashout float64 `json:"autoCashout"`
+	// OnWinPercent and OnLossPercent raise the stake of a custom strategy
+	// by a percentage of the last stake; zero returns to the base amount.
+	OnWinPercent  float64 `json:"onWinPercent,omitempty"`
+	OnLossPercent float64 `json:"onLossPercent,omitempty"`
+	// Script sets the bet and cashout target of every round of a script
+	// strategy; BaseAmount and AutoCashout are where it starts from.
+	Script string         `json:"script,omitempty"`
+	Stop   StopConditions `json:"stop"`
+}
+
+func (c Config) Validate() error {
+	_, err := c.compile()
+	return err
+}
+
+// compile validates the config and compiles its script, if any.
+func (c Config) compile() (*script.Program, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	if c.Strategy != Script {
+		return nil, nil
+	}
+
+	program, err := script.Compile(c.Script, script.DefaultLimits())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	// A dry run after a lost round catches a script that cannot run at all
+	_, err = program.Run(script.Input{
+		Balance: 100 * c.BaseAmount, Base: c.BaseAmount, Rounds: 1, Losses: 1,
+		Wagered: c.BaseAmount, Profit: -c.BaseAmount, Bet: c.BaseAmount, Cashout: c.AutoCashout,
+		History: []script.Round{{Stake: c.BaseAmount, CrashPoint: 1}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	return program, nil
+}
+
+func (c Config) validate() error {
+	switch {
+	case c.Strategy != Flat && c.Strategy != Martingale && c.Strategy != AntiMartingale && c.Strategy != Custom &&
+		c.Strategy != Script:
+		return fmt.Errorf("%w: unknown strategy %q", ErrInvalidConfig, c.Strategy)
+	case c.BaseAmount <= 0:
+		return fmt.Errorf("%w: base amount must be positive", ErrInvalidConfig)
+	case c.AutoCashout <= 1:
+		return fmt.Errorf("%w: auto-cashout must be above 1", ErrInvalidConfig)
+	case c.OnWinPercent < 0 || c.OnLossPercent < 0:
+		return fmt.Errorf("%w: increase percentages must not be negative", ErrInvalidConfig)
+	case c.Stop.Rounds < 0 || c.Stop.ProfitTarget < 0 || c.Stop.LossLimit < 0 || c.Stop.BalanceFloor < 0:
+		return fmt.Errorf("%w: stop conditions must not be negative", ErrInvalidConfig)
+	case c.Strategy == Script && c.Script == "":
+		return fmt.Errorf("%w: script is required", ErrInvalidConfig)
+	case c.Strategy != Script && c.Script != "":
+		return fmt.Errorf("%w: script needs the script strategy", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// Bet is the next bet of a session.
+type Bet struct {
+	Amount      float64
+	AutoCashout float64
+}
+
+// Progress is how far a session got.
+type Progress struct {
+	Rounds     int     `json:"rounds"`
+	Wins       int     `json:"wins"`
+	Losses     int     `json:"losses"`
+	Wagered    float64 `json:"wagered"`
+	Profit     float64 `json:"profit"`
+	NextAmount float64 `json:"nextAmount"`
+	StopReason string  `json:"stopReason,omitempty"`
+}
+
+// Session follows the bets of one auto-bet run. It is not safe for
+// concurrent use.
+type Session struct {
+	config   Config
+	program  *script.Program
+	progress Progress
+	history  []script.Round // latest first
+	err      error
+}
+
+// NewSession starts a session for a valid config.
+func NewSession(config Config) (*Session, error) {
+	program, err := config.compile()
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		config:   config,
+		program:  program,
+		progress: Progress{NextAmount: config.BaseAmount},
+	}, nil
+}
+
+func (s *Session) Config() Config {
+	return s.config
+}
+
+func (s *Session) Progress() Progress {
+	return s.progress
+}
+
+func (s *Session) Stopped() bool {
+	return s.progress.StopReason != ""
+}
+
+// Err returns why the session's script failed, if it did.
+func (s *Session) Err() error {
+	return s.err
+}
+
+// Stop ends the session for reason, unless it already stopped.
+func (s *Session) Stop(reason string) {
+	if s.progress.StopReason == "" {
+		s.progress.StopReason = reason
+	}
+}
+
+// NextBet returns the next bet for a player with balance, or false after
+// stopping the session if it must not bet again.
+func (s *Session) NextBet(balance float64) (Bet, bool) {
+	if s.Stopped() {
+		return Bet{}, false
+	}
+
+	bet := Bet{Amount: s.progress.NextAmount, AutoCashout: s.config.AutoCashout}
+	if s.program != nil {
+		var ok bool
+		if bet, ok = s.scriptBet(balance); !ok {
+			return Bet{}, false
+		}
+		s.progress.NextAmount = bet.Amount
+	}
+
+	if s.config.Stop.BalanceFloor > 0 && balance-bet.Amount < s.config.Stop.BalanceFloor {
+		s.Stop(StopBalanceFloor)
+		return Bet{}, false
+	}
+	return bet, true
+}
+
+// scriptBet runs the session's script for the next bet, stopping the session
+// if the script stops it or fails.
+func (s *Session) scriptBet(balance float64) (Bet, bool) {
+	p := s.progress
+	out, err := s.program.Run(script.Input{
+		Balance: balance,
+		Base:    s.config.BaseAmount,
+		Rounds:  p.Rounds,
+		Wins:    p.Wins,
+		Losses:  p.Losses,
+		Wagered: p.Wagered,
+		Profit:  p.Profit,
+		Bet:     p.NextAmount,
+		Cashout: s.config.AutoCashout,
+		History: s.history,
+	})
+	if err == nil && out.Stop {
+		s.Stop(StopByScript)
+		return Bet{}, false
+	}
+
+	bet := Bet{Amount: math.Round(out.Bet*100) / 100, AutoCashout: math.Round(out.Cashout*100) / 100}
+	if err == nil && (bet.Amount <= 0 || bet.AutoCashout <= 1) {
+		err = fmt.Errorf("%w: bet must be positive and cashout above 1, got %.2f at %.2fx",
+			script.ErrRuntime, bet.Amount, bet.AutoCashout)
+	}
+	if err != nil {
+		s.err = err
+		s.Stop(StopScriptError)
+		return Bet{}, false
+	}
+	return bet, true
+}
+
+// Record adds the outcome of a bet of stake that paid win in a round that
+// crashed at crashPoint, sets the stake of the next bet and stops the
+// session once a stop condition is met.
+func (s *Session) Record(stake, win, crashPoint float64) {
+	p := &s.progress
+	p.Rounds++
+	p.Wagered += stake
+	p.Profit += win - stake
+
+	// A bet that got its stake back, such as one the round's limits cashed
+	// out at 1.00x, is not a loss
+	won := win >= stake
+	if won {
+		p.Wins++
+	} else {
+		p.Losses++
+	}
+	if s.program == nil {
+		p.NextAmount = s.nextAmount(stake, won)
+	}
+
+	round := script.Round{Stake: stake, Payout: win, CrashPoint: crashPoint}
+	if win > 0 {
+		round.Multiplier = math.Round(win/stake*100) / 100
+	}
+	s.history = append([]script.Round{round}, s.history...)
+	if len(s.history) > script.MaxHistory {
+		s.history = s.history[:script.MaxHistory]
+	}
+
+	stop := s.config.Stop
+	switch {
+	case stop.ProfitTarget > 0 && p.Profit >= stop.ProfitTarget:
+		s.Stop(StopProfitTarget)
+	case stop.LossLimit > 0 && -p.Profit >= stop.LossLimit:
+		s.Stop(StopLossLimit)
+	case stop.Rounds > 0 && p.Rounds >= stop.Rounds:
+		s.Stop(StopRounds)
+	}
+}
+
+// nextAmount returns the stake following a bet of stake, rounded to cents.
+func (s *Session) nextAmount(stake float64, won bool) float64 {
+	base := s.config.BaseAmount
+	var next float64
+	switch s.config.Strategy {
+	case Martingale:
+		next = base
+		if !won {
+			next = stake * 2
+		}
+	case AntiMartingale:
+		next = base
+		if won {
+			next = stake * 2
+		}
+	case Custom:
+		percent := s.config.OnLossPercent
+		if won {
+			percent = s.config.OnWinPercent
+		}
+		next = base
+		if percent > 0 {
+			next = stake * (1 + percent/100)
+		}
+	default:
+		next = base
+	}
+	return math.Round(next*100) / 100
+}
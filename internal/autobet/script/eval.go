@@ -0,0 +1,281 @@
+package script
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+type stmt interface {
+	exec(m *machine) error
+}
+
+type expr interface {
+	eval(m *machine) (float64, error)
+}
+
+type assignStmt struct {
+	name  string
+	value expr
+	line  int
+}
+
+type ifStmt struct {
+	cond      expr
+	then, els []stmt
+}
+
+type numberExpr struct {
+	value float64
+}
+
+type varExpr struct {
+	name string
+	line int
+}
+
+type unaryExpr struct {
+	op string
+	x  expr
+}
+
+type binaryExpr struct {
+	op   string
+	x, y expr
+	line int
+}
+
+type condExpr struct {
+	cond, then, els expr
+}
+
+type callExpr struct {
+	name string
+	args []expr
+	line int
+}
+
+// machine runs a script, counting its steps against the limits.
+type machine struct {
+	in       Input
+	vars     map[string]float64
+	steps    int
+	maxSteps int
+	deadline time.Time
+}
+
+// timeCheckEvery is how many steps run between checks of the deadline.
+const timeCheckEvery = 64
+
+func (m *machine) step() error {
+	m.steps++
+	if m.steps > m.maxSteps {
+		return ErrStepLimit
+	}
+	if m.steps%timeCheckEvery == 0 && time.Now().After(m.deadline) {
+		return ErrTimeLimit
+	}
+	return nil
+}
+
+func (m *machine) run(body []stmt) (err error) {
+	// A bug in the interpreter must not take the server down
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrRuntime, r)
+		}
+	}()
+
+	for _, s := range body {
+		if err := s.exec(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *assignStmt) exec(m *machine) error {
+	value, err := s.value.eval(m)
+	if err != nil {
+		return err
+	}
+	m.vars[s.name] = value
+	return nil
+}
+
+func (s *ifStmt) exec(m *machine) error {
+	cond, err := s.cond.eval(m)
+	if err != nil {
+		return err
+	}
+	body := s.els
+	if cond != 0 {
+		body = s.then
+	}
+	for _, s := range body {
+		if err := s.exec(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *numberExpr) eval(m *machine) (float64, error) {
+	return e.value, m.step()
+}
+
+func (e *varExpr) eval(m *machine) (float64, error) {
+	value, set := m.vars[e.name]
+	if !set {
+		return 0, runtimeError(e.line, "%s is not set", e.name)
+	}
+	return value, m.step()
+}
+
+func (e *unaryExpr) eval(m *machine) (float64, error) {
+	x, err := e.x.eval(m)
+	if err != nil {
+		return 0, err
+	}
+	if e.op == "!" {
+		return truth(x == 0), m.step()
+	}
+	return -x, m.step()
+}
+
+func (e *condExpr) eval(m *machine) (float64, error) {
+	cond, err := e.cond.eval(m)
+	if err != nil {
+		return 0, err
+	}
+	if err := m.step(); err != nil {
+		return 0, err
+	}
+	if cond != 0 {
+		return e.then.eval(m)
+	}
+	return e.els.eval(m)
+}
+
+func (e *binaryExpr) eval(m *machine) (float64, error) {
+	x, err := e.x.eval(m)
+	if err != nil {
+		return 0, err
+	}
+	if err := m.step(); err != nil {
+		return 0, err
+	}
+
+	// && and || only evaluate their right side when it decides
+	switch {
+	case e.op == "&&" && x == 0:
+		return 0, nil
+	case e.op == "||" && x != 0:
+		return 1, nil
+	}
+	y, err := e.y.eval(m)
+	if err != nil {
+		return 0, err
+	}
+
+	var result float64
+	switch e.op {
+	case "&&", "||":
+		result = truth(y != 0)
+	case "==":
+		result = truth(x == y)
+	case "!=":
+		result = truth(x != y)
+	case "<":
+		result = truth(x < y)
+	case "<=":
+		result = truth(x <= y)
+	case ">":
+		result = truth(x > y)
+	case ">=":
+		result = truth(x >= y)
+	case "+":
+		result = x + y
+	case "-":
+		result = x - y
+	case "*":
+		result = x * y
+	case "/", "%":
+		if y == 0 {
+			return 0, runtimeError(e.line, "division by zero")
+		}
+		if e.op == "/" {
+			result = x / y
+		} else {
+			result = math.Mod(x, y)
+		}
+	}
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return 0, runtimeError(e.line, "number out of range")
+	}
+	return result, nil
+}
+
+func (e *callExpr) eval(m *machine) (float64, error) {
+	args := make([]float64, len(e.args))
+	for i, arg := range e.args {
+		value, err := arg.eval(m)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = value
+	}
+	if err := m.step(); err != nil {
+		return 0, err
+	}
+
+	switch e.name {
+	case "min", "max":
+		result := args[0]
+		for _, value := range args[1:] {
+			if (e.name == "min" && value < result) || (e.name == "max" && value > result) {
+				result = value
+			}
+		}
+		return result, nil
+	case "abs":
+		return math.Abs(args[0]), nil
+	case "floor":
+		return math.Floor(args[0]), nil
+	case "ceil":
+		return math.Ceil(args[0]), nil
+	case "cents":
+		return math.Round(args[0]*100) / 100, nil
+	case "clamp":
+		return math.Min(math.Max(args[0], args[1]), args[2]), nil
+	}
+
+	// The history functions look back args[0] rounds, 1 being the last;
+	// rounds not played yet are all zero
+	var r Round
+	if back := int(args[0]); back >= 1 && back <= len(m.in.History) {
+		r = m.in.History[back-1]
+	}
+	switch e.name {
+	case "crash":
+		return r.CrashPoint, nil
+	case "stake":
+		return r.Stake, nil
+	case "payout":
+		return r.Payout, nil
+	case "multiplier":
+		return r.Multiplier, nil
+	}
+	return 0, runtimeError(e.line, "unknown function %s", e.name)
+}
+
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func runtimeError(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: line %d: "+format, append([]interface{}{ErrRuntime, line}, args...)...)
+}
@@ -0,0 +1,531 @@
+package script
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxDepth bounds the nesting of expressions and blocks.
+const maxDepth = 64
+
+type tokenKind int
+
+const (
+	tEOF tokenKind = iota
+	tNewline
+	tNumber
+	tIdent
+	tOp
+	tIf
+	tElse
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	line int
+}
+
+func (t token) is(op string) bool {
+	return t.kind == tOp && t.text == op
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tEOF:
+		return "end of script"
+	case tNewline:
+		return "end of line"
+	}
+	return strconv.Quote(t.text)
+}
+
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+var twoCharOps = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return l.token()
+		}
+	}
+	return token{kind: tEOF, line: l.line}, nil
+}
+
+func (l *lexer) token() (token, error) {
+	start, line := l.pos, l.line
+	c := l.src[l.pos]
+
+	switch {
+	case c == '\n' || c == ';':
+		l.pos++
+		if c == '\n' {
+			l.line++
+		}
+		return token{kind: tNewline, text: string(c), line: line}, nil
+
+	case isDigit(c) || c == '.':
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		text := l.src[start:l.pos]
+		num, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return token{}, invalid(line, "bad number %q", text)
+		}
+		return token{kind: tNumber, text: text, num: num, line: line}, nil
+
+	case isLetter(c):
+		for l.pos < len(l.src) && (isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		text := l.src[start:l.pos]
+		switch text {
+		case "if":
+			return token{kind: tIf, text: text, line: line}, nil
+		case "else":
+			return token{kind: tElse, text: text, line: line}, nil
+		case "true":
+			return token{kind: tNumber, text: text, num: 1, line: line}, nil
+		case "false":
+			return token{kind: tNumber, text: text, num: 0, line: line}, nil
+		}
+		return token{kind: tIdent, text: text, line: line}, nil
+	}
+
+	for _, op := range twoCharOps {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += 2
+			return token{kind: tOp, text: op, line: line}, nil
+		}
+	}
+	if strings.IndexByte("+-*/%(),?:<>!={}", c) >= 0 {
+		l.pos++
+		return token{kind: tOp, text: string(c), line: line}, nil
+	}
+	return token{}, invalid(line, "unexpected character %q", c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// parser builds the statements of a script, one token ahead, counting what
+// the script will cost against its limits.
+type parser struct {
+	lexer  lexer
+	tok    token
+	peeked bool
+	limits Limits
+	nodes  int
+	depth  int
+	vars   map[string]bool // variables that can be read
+	locals int
+}
+
+func (p *parser) peek() (token, error) {
+	if !p.peeked {
+		t, err := p.lexer.next()
+		if err != nil {
+			return token{}, err
+		}
+		p.tok, p.peeked = t, true
+	}
+	return p.tok, nil
+}
+
+func (p *parser) advance() (token, error) {
+	t, err := p.peek()
+	p.peeked = false
+	return t, err
+}
+
+func (p *parser) expect(op string) error {
+	t, err := p.advance()
+	if err != nil {
+		return err
+	}
+	if !t.is(op) {
+		return invalid(t.line, "expected %q, found %s", op, t)
+	}
+	return nil
+}
+
+// node counts a node of the parsed script.
+func (p *parser) node(line int) error {
+	p.nodes++
+	if p.nodes > p.limits.MaxNodes {
+		return invalid(line, "script is too large")
+	}
+	return nil
+}
+
+func (p *parser) enter(line int) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return invalid(line, "script is nested too deeply")
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) parse() ([]stmt, error) {
+	body, err := p.stmts()
+	if err != nil {
+		return nil, err
+	}
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.kind != tEOF {
+		return nil, invalid(t.line, "unexpected %s", t)
+	}
+	return body, nil
+}
+
+func (p *parser) stmts() ([]stmt, error) {
+	var body []stmt
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case t.kind == tNewline:
+			p.advance()
+			continue
+		case t.kind == tEOF || t.is("}"):
+			return body, nil
+		}
+
+		s, err := p.stmt()
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, s)
+
+		// A statement ends its line
+		if t, err = p.peek(); err != nil {
+			return nil, err
+		}
+		if t.kind != tNewline && t.kind != tEOF && !t.is("}") {
+			return nil, invalid(t.line, "unexpected %s", t)
+		}
+	}
+}
+
+func (p *parser) stmt() (stmt, error) {
+	t, err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.node(t.line); err != nil {
+		return nil, err
+	}
+
+	switch t.kind {
+	case tIf:
+		return p.ifStmt(t.line)
+
+	case tIdent:
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		for _, name := range inputs {
+			if t.text == name {
+				return nil, invalid(t.line, "cannot assign to %s", name)
+			}
+		}
+		if _, isFunc := functions[t.text]; isFunc {
+			return nil, invalid(t.line, "cannot assign to %s", t.text)
+		}
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.vars[t.text] {
+			p.locals++
+			if p.locals > p.limits.MaxVars {
+				return nil, invalid(t.line, "script sets more than %d variables", p.limits.MaxVars)
+			}
+			p.vars[t.text] = true
+		}
+		return &assignStmt{name: t.text, value: value, line: t.line}, nil
+	}
+	return nil, invalid(t.line, "expected a statement, found %s", t)
+}
+
+func (p *parser) ifStmt(line int) (stmt, error) {
+	if err := p.enter(line); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	cond, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	then, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	s := &ifStmt{cond: cond, then: then}
+
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.kind != tElse {
+		return s, nil
+	}
+	p.advance()
+
+	if t, err = p.peek(); err != nil {
+		return nil, err
+	}
+	if t.kind == tIf {
+		p.advance()
+		elseIf, err := p.ifStmt(t.line)
+		if err != nil {
+			return nil, err
+		}
+		s.els = []stmt{elseIf}
+		return s, nil
+	}
+	if s.els, err = p.block(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (p *parser) block() ([]stmt, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	body, err := p.stmts()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (p *parser) expr() (expr, error) {
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.enter(t.line); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	cond, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if t, err = p.peek(); err != nil {
+		return nil, err
+	}
+	if !t.is("?") {
+		return cond, nil
+	}
+	p.advance()
+
+	then, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	els, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.node(t.line); err != nil {
+		return nil, err
+	}
+	return &condExpr{cond: cond, then: then, els: els}, nil
+}
+
+// precedence lists the binary operators from the loosest binding.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) (expr, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+
+	x, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.kind != tOp || !contains(precedence[level], t.text) {
+			return x, nil
+		}
+		p.advance()
+
+		y, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.node(t.line); err != nil {
+			return nil, err
+		}
+		x = &binaryExpr{op: t.text, x: x, y: y, line: t.line}
+	}
+}
+
+func (p *parser) unary() (expr, error) {
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if !t.is("-") && !t.is("!") {
+		return p.primary()
+	}
+	p.advance()
+
+	if err := p.enter(t.line); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.node(t.line); err != nil {
+		return nil, err
+	}
+	return &unaryExpr{op: t.text, x: x}, nil
+}
+
+func (p *parser) primary() (expr, error) {
+	t, err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.node(t.line); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case t.kind == tNumber:
+		return &numberExpr{value: t.num}, nil
+
+	case t.is("("):
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+
+	case t.kind == tIdent:
+		next, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if next.is("(") {
+			return p.call(t)
+		}
+		if !p.vars[t.text] {
+			return nil, invalid(t.line, "unknown variable %s", t.text)
+		}
+		return &varExpr{name: t.text, line: t.line}, nil
+	}
+	return nil, invalid(t.line, "expected a value, found %s", t)
+}
+
+func (p *parser) call(name token) (expr, error) {
+	arity, exists := functions[name.text]
+	if !exists {
+		return nil, invalid(name.line, "unknown function %s", name.text)
+	}
+	p.advance()
+
+	var args []expr
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.is(")") && len(args) == 0 {
+			p.advance()
+			break
+		}
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		if t, err = p.advance(); err != nil {
+			return nil, err
+		}
+		if t.is(")") {
+			break
+		}
+		if !t.is(",") {
+			return nil, invalid(t.line, "expected \",\" or \")\", found %s", t)
+		}
+	}
+
+	if (arity < 0 && len(args) == 0) || (arity >= 0 && len(args) != arity) {
+		return nil, invalid(name.line, "wrong number of arguments to %s", name.text)
+	}
+	return &callExpr{name: name.text, args: args, line: name.line}, nil
+}
+
+func contains(ops []string, op string) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// invalid reports a script that does not compile.
+func invalid(line int, format string, args ...interface{}) error {
+	if line == 0 {
+		return fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidScript}, args...)...)
+	}
+	return fmt.Errorf("%w: line %d: "+format, append([]interface{}{ErrInvalidScript, line}, args...)...)
+}
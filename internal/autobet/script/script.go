@@ -0,0 +1,152 @@
+// Package script runs auto-bet strategy scripts written by players.
+//
+// A script is a list of statements over numbers: assignments (name = expr)
+// and if/else blocks, one statement per line or separated by semicolons. It
+// reads the player's balance, the session's progress and the latest rounds,
+// and sets bet, cashout and stop:
+//
+//	# martingale with a cap
+//	if payout(1) > 0 { bet = base } else { bet = min(stake(1) * 2, 100) }
+//	cashout = crash(1) < 1.5 ? 1.5 : 2
+//	stop = balance < 50
+//
+// Expressions have + - * / %, comparisons, && || !, c ? a : b and the
+// functions min, max, abs, floor, ceil, cents (rounding to cents), clamp,
+// crash, stake, payout and multiplier; comparisons give 1 or 0 and any
+// non-zero number is true.
+// Scripts have no loops, strings or access to anything but their inputs, so
+// their running time and memory follow from their size, which is limited
+// when they are compiled; evaluation is bounded by steps and a deadline on
+// top of that.
+package script
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidScript = errors.New("invalid script")
+	ErrRuntime       = errors.New("script failed")
+	ErrStepLimit     = errors.New("script exceeded its step limit")
+	ErrTimeLimit     = errors.New("script exceeded its time limit")
+)
+
+// Limits bound what a script may cost.
+type Limits struct {
+	// MaxSource is the largest script accepted, in bytes.
+	MaxSource int
+	// MaxNodes bounds the size of the parsed script, and so its memory.
+	MaxNodes int
+	// MaxVars bounds the variables a script may set.
+	MaxVars int
+	// MaxSteps bounds the expressions evaluated in one run.
+	MaxSteps int
+	// Timeout bounds the wall time of one run.
+	Timeout time.Duration
+}
+
+func DefaultLimits() Limits {
+	return Limits{
+		MaxSource: 4096,
+		MaxNodes:  2000,
+		MaxVars:   32,
+		MaxSteps:  10000,
+		Timeout:   10 * time.Millisecond,
+	}
+}
+
+// MaxHistory is how many rounds back a script can look.
+const MaxHistory = 20
+
+// Round is the outcome of one of the session's bets.
+type Round struct {
+	Stake      float64
+	Payout     float64
+	Multiplier float64 // the bet's cashout multiplier, 0 if it lost
+	CrashPoint float64
+}
+
+// Input is what a script can read. Bet and Cashout are the values of bet and
+// cashout until the script sets them.
+type Input struct {
+	Balance float64
+	Base    float64
+	Rounds  int
+	Wins    int
+	Losses  int
+	Wagered float64
+	Profit  float64
+	Bet     float64
+	Cashout float64
+	// History holds the latest rounds, the last one first.
+	History []Round
+}
+
+// Output is what a script decided.
+type Output struct {
+	Bet     float64
+	Cashout float64
+	Stop    bool
+}
+
+// Program is a compiled script, safe for concurrent use.
+type Program struct {
+	body   []stmt
+	limits Limits
+}
+
+// inputs are the variables a script reads and cannot set.
+var inputs = []string{"balance", "base", "rounds", "wins", "losses", "wagered", "profit"}
+
+// outputs are the variables a script sets.
+var outputs = []string{"bet", "cashout", "stop"}
+
+// functions are the functions a script can call, by name, with their
+// number of arguments; -1 takes one or more.
+var functions = map[string]int{
+	"min": -1, "max": -1, "abs": 1, "floor": 1, "ceil": 1, "cents": 1, "clamp": 3,
+	"crash": 1, "stake": 1, "payout": 1, "multiplier": 1,
+}
+
+// Compile parses and checks a script within limits.
+func Compile(source string, limits Limits) (*Program, error) {
+	if len(source) > limits.MaxSource {
+		return nil, invalid(0, "script is longer than %d bytes", limits.MaxSource)
+	}
+
+	p := &parser{lexer: lexer{src: source, line: 1}, limits: limits, vars: make(map[string]bool)}
+	for _, name := range inputs {
+		p.vars[name] = true
+	}
+	for _, name := range outputs {
+		p.vars[name] = true
+	}
+	body, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Program{body: body, limits: limits}, nil
+}
+
+// Run runs the script on in.
+func (p *Program) Run(in Input) (out Output, err error) {
+	m := &machine{
+		in:       in,
+		vars:     make(map[string]float64, len(inputs)+len(outputs)),
+		maxSteps: p.limits.MaxSteps,
+		deadline: time.Now().Add(p.limits.Timeout),
+	}
+	for name, value := range map[string]float64{
+		"balance": in.Balance, "base": in.Base, "rounds": float64(in.Rounds), "wins": float64(in.Wins),
+		"losses": float64(in.Losses), "wagered": in.Wagered, "profit": in.Profit,
+		"bet": in.Bet, "cashout": in.Cashout, "stop": 0,
+	} {
+		m.vars[name] = value
+	}
+
+	if err := m.run(p.body); err != nil {
+		return Output{}, err
+	}
+	return Output{Bet: m.vars["bet"], Cashout: m.vars["cashout"], Stop: m.vars["stop"] != 0}, nil
+}
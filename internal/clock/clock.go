@@ -0,0 +1,172 @@
+// Package clock abstracts time so round timing can be driven manually in
+// tests.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the game loop.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+type Timer interface {
+	Stop() bool
+}
+
+// Real returns the system clock.
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// Manual is a clock that only moves when advanced. Sleeps and timers fire
+// once the clock has been advanced past their deadline.
+type Manual struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+	changed chan struct{}
+}
+
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+	fn       func()
+}
+
+// NewManual returns a manual clock set to start.
+func NewManual(start time.Time) *Manual {
+	return &Manual{now: start, changed: make(chan struct{})}
+}
+
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+func (m *Manual) Since(t time.Time) time.Duration {
+	return m.Now().Sub(t)
+}
+
+func (m *Manual) Sleep(d time.Duration) {
+	<-m.After(d)
+}
+
+func (m *Manual) After(d time.Duration) <-chan time.Time {
+	w := &waiter{ch: make(chan time.Time, 1)}
+	m.add(w, d)
+	return w.ch
+}
+
+func (m *Manual) AfterFunc(d time.Duration, f func()) Timer {
+	w := &waiter{fn: f}
+	m.add(w, d)
+	return &manualTimer{clock: m, waiter: w}
+}
+
+func (m *Manual) add(w *waiter, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.deadline = m.now.Add(d)
+	if d <= 0 {
+		m.fire(w)
+		return
+	}
+	m.waiters = append(m.waiters, w)
+	m.notify()
+}
+
+// Advance moves the clock forward and fires every sleep and timer whose
+// deadline has passed, earliest first.
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = m.now.Add(d)
+	sort.SliceStable(m.waiters, func(i, j int) bool {
+		return m.waiters[i].deadline.Before(m.waiters[j].deadline)
+	})
+
+	remaining := m.waiters[:0]
+	for _, w := range m.waiters {
+		if w.deadline.After(m.now) {
+			remaining = append(remaining, w)
+			continue
+		}
+		m.fire(w)
+	}
+	m.waiters = remaining
+	m.notify()
+}
+
+// BlockUntil waits until at least n sleeps or timers are pending. Tests call
+// it before Advance so the goroutine under test has reached its next wait.
+func (m *Manual) BlockUntil(n int) {
+	for {
+		m.mu.Lock()
+		pending, changed := len(m.waiters), m.changed
+		m.mu.Unlock()
+
+		if pending >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+func (m *Manual) fire(w *waiter) {
+	if w.fn != nil {
+		go w.fn()
+		return
+	}
+	w.ch <- m.now
+}
+
+// notify wakes BlockUntil callers. The caller must hold mu.
+func (m *Manual) notify() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+func (m *Manual) remove(target *waiter) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, w := range m.waiters {
+		if w == target {
+			m.waiters = append(m.waiters[:i], m.waiters[i+1:]...)
+			m.notify()
+			return true
+		}
+	}
+	return false
+}
+
+type manualTimer struct {
+	clock  *Manual
+	waiter *waiter
+}
+
+func (t *manualTimer) Stop() bool {
+	return t.clock.remove(t.waiter)
+}
@@ -5,6 +5,33 @@ import (
 	"encoding/json"
 )
 
+// GetAdminByUsername returns the admin account and its password hash.
+func (d *Database) GetAdminByUsername(username string) (*models.AdminUser, string, error) {
+	var admin models.AdminUser
+	var passwordHash string
+	err := d.db.QueryRow(`
+        SELECT id, username, password_hash, role, created_at
+        FROM admin_users WHERE username = $1`,
+		username).Scan(&admin.ID, &admin.Username, &passwordHash, &admin.Role, &admin.CreatedAt)
+	if err != nil {
+		return nil, "", err
+	}
+	return &admin, passwordHash, nil
+}
+
+func (d *Database) LogAdminAction(adminID int, actionType, targetType, targetID string, details interface{}) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(`
+        INSERT INTO admin_actions (admin_id, action_type, target_type, target_id, details)
+        VALUES ($1, $2, $3, $4, $5)`,
+		adminID, actionType, targetType, targetID, detailsJSON)
+	return err
+}
+
 func (d *Database) GetPendingWithdrawals() ([]models.WithdrawalRequest, error) {
 	rows, err := d.db.Query(`
         SELECT w.id, w.user_id, w.amount, w.status, w.created_at,
@@ -19,7 +19,7 @@ func (d *Database) GetDashboardStats() (*models.DashboardStats, error) {
 	err = tx.QueryRow(`
         SELECT 
             (SELECT COUNT(*) FROM users),
-            (SELECT COUNT(DISTINCT user_id) FROM bets WHERE created_at > NOW() - INTERVAL '24 hours')
+            (SELECT COUNT(DISTINCT user_id) FROM bets WHERE created_at > NOW() - INTERVAL '24 hours' AND cancelled_at IS NULL)
     `).Scan(&stats.TotalUsers, &stats.ActiveUsers24h)
 	if err != nil {
 		return nil, err
@@ -33,7 +33,7 @@ func (d *Database) GetDashboardStats() (*models.DashboardStats, error) {
             COALESCE(SUM(CASE WHEN win_amount > amount THEN amount - win_amount ELSE amount END), 0),
             COALESCE(AVG(CASE WHEN cashed_out THEN cashout_multiplier ELSE 0 END), 0)
         FROM bets 
-        WHERE created_at > NOW() - INTERVAL '24 hours'
+        WHERE created_at > NOW() - INTERVAL '24 hours' AND cancelled_at IS NULL
     `).Scan(
 		&stats.TotalBets24h,
 		&stats.TotalVolume24h,
@@ -82,7 +82,7 @@ func (d *Database) GetUserManagementData(filters map[string]interface{}, page, l
             u.verification_level,
             ARRAY(SELECT note FROM user_notes WHERE user_id = u.id ORDER BY created_at DESC LIMIT 5) as recent_notes
         FROM users u
-        LEFT JOIN bets b ON u.id = b.user_id
+        LEFT JOIN bets b ON u.id = b.user_id AND b.cancelled_at IS NULL
         %s
         GROUP BY u.id
         ORDER BY u.created_at DESC
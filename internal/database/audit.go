@@ -0,0 +1,72 @@
+package database
+
+import (
+	"crash-game/internal/game"
+	"fmt"
+	"time"
+)
+
+// AuditFilter selects the rounds of a statistical audit. Zero times leave the
+// range open.
+type AuditFilter struct {
+	Distribution string
+	From         time.Time
+	To           time.Time
+}
+
+// where returns the conditions on the games table g. Only crashed rounds are
+// audited. Rounds recorded before distributions were stored were played with
+// the default one.
+func (f AuditFilter) where() (string, []interface{}) {
+	spec := f.Distribution
+	if spec == "" {
+		spec = game.DefaultDistributionSpec
+	}
+
+	where := `COALESCE(g.status, 'crashed') = 'crashed' AND COALESCE(g.distribution, $1) = $2`
+	args := []interface{}{game.DefaultDistributionSpec, spec}
+	if !f.From.IsZero() {
+		args = append(args, f.From)
+		where += fmt.Sprintf(" AND g.start_time >= $%d", len(args))
+	}
+	if !f.To.IsZero() {
+		args = append(args, f.To)
+		where += fmt.Sprintf(" AND g.start_time < $%d", len(args))
+	}
+	return where, args
+}
+
+func (d *Database) GetAuditCrashPoints(filter AuditFilter) ([]float64, error) {
+	where, args := filter.where()
+	rows, err := d.db.Query(`
+		SELECT g.crash_point
+		FROM games g
+		WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []float64
+	for rows.Next() {
+		var point float64
+		if err := rows.Scan(&point); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// GetAuditWagers returns the amounts bet and paid out over the audited rounds.
+func (d *Database) GetAuditWagers(filter AuditFilter) (float64, float64, error) {
+	where, args := filter.where()
+
+	var wagered, paid float64
+	err := d.db.QueryRow(`
+		SELECT COALESCE(SUM(b.amount), 0), COALESCE(SUM(b.win_amount), 0)
+		FROM bets b
+		JOIN games g ON g.game_id = b.game_id
+		WHERE b.cancelled_at IS NULL AND `+where, args...).Scan(&wagered, &paid)
+	return wagered, paid, err
+}
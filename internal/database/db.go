@@ -44,14 +44,17 @@ func (d *Database) SaveGame(game *models.GameHistory) error {
 	return nil
 }
 
-func (d *Database) GetGameHistory(userID string) ([]models.GameHistory, error) {
+// GetGameHistory returns the latest rounds of a room, or of all rooms if
+// roomID is empty.
+func (d *Database) GetGameHistory(roomID string) ([]models.GameHistory, error) {
 	rows, err := d.db.Query(`
-			SELECT g.game_id, g.crash_point, g.start_time, g.end_time, g.hash
+			SELECT g.game_id, COALESCE(g.room_id, ''), g.round, COALESCE(g.server_seed_id, 0), COALESCE(g.client_seed, ''),
+				COALESCE(g.distribution, ''), g.crash_point, g.start_time, g.end_time, g.hash
 			FROM games g
-			LEFT JOIN bets b ON g.game_id = b.game_id AND b.user_id = $1::uuid
+			WHERE ($1 = '' OR g.room_id = $1) AND COALESCE(g.status, 'crashed') = 'crashed'
 			ORDER BY g.start_time DESC
 			LIMIT 50
-		`, userID)
+		`, roomID)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +63,8 @@ func (d *Database) GetGameHistory(userID string) ([]models.GameHistory, error) {
 	var history []models.GameHistory
 	for rows.Next() {
 		var h models.GameHistory
-		err := rows.Scan(&h.GameID, &h.CrashPoint, &h.StartTime, &h.EndTime, &h.Hash)
+		err := rows.Scan(&h.GameID, &h.RoomID, &h.Round, &h.ServerSeedID, &h.ClientSeed,
+			&h.Distribution, &h.CrashPoint, &h.StartTime, &h.EndTime, &h.Hash)
 		if err != nil {
 			return nil, err
 		}
@@ -176,72 +180,17 @@ func (d *Database) MarkNotificationRead(notificationID string, adminID string) e
 	return nil
 }
 
-func (d *Database) SaveGameHistory(history *models.GameHistory) error {
-	log.Printf("📝 Starting SaveGameHistory for game %s with %d players",
-		history.GameID, len(history.Players))
-
-	tx, err := d.db.Begin()
-	if err != nil {
-		log.Printf("❌ Failed to begin transaction: %v", err)
-		return err
-	}
-	defer tx.Rollback()
-
-	// Insert game first
-	result, err := tx.Exec(`
-		INSERT INTO games (game_id, crash_point, start_time, end_time, hash, status)
-		VALUES ($1::uuid, $2, $3, $4, $5, $6)
-	`, history.GameID, history.CrashPoint, history.StartTime, history.EndTime, history.Hash, history.Status)
-
-	if err != nil {
-		log.Printf("❌ Failed to insert game: %v", err)
-		return err
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	log.Printf("✅ Game inserted: ID=%s, Status=%s, Rows=%d",
-		history.GameID, history.Status, rowsAffected)
-
-	// Insert bets
-	for _, player := range history.Players {
-		log.Printf("👤 Inserting bet - Game: %s, User: %s, Amount: %.2f",
-			history.GameID, player.UserID, player.BetAmount)
-
-		result, err = tx.Exec(`
-			INSERT INTO bets (game_id, user_id, amount, win_amount, cashed_out, cashout_at, auto_cashout)
-			VALUES ($1::uuid, $2::uuid, $3, $4, $5, $6, $7)
-		`, history.GameID, player.UserID, player.BetAmount, player.WinAmount,
-			player.CashedOut, player.CashoutAt, player.AutoCashout)
-
-		if err != nil {
-			log.Printf("❌ Failed to insert bet for user %s: %v", player.UserID, err)
-			return err
-		}
-
-		rowsAffected, _ = result.RowsAffected()
-		log.Printf("✅ Bet inserted: Game=%s, User=%s, Amount=%.2f, Win=%.2f, Rows=%d",
-			history.GameID, player.UserID, player.BetAmount, player.WinAmount, rowsAffected)
-	}
-
-	if err := tx.Commit(); err != nil {
-		log.Printf("❌ Failed to commit transaction: %v", err)
-		return err
-	}
-
-	log.Printf("✅ Successfully saved game history for %s with %d players",
-		history.GameID, len(history.Players))
-	return nil
-}
-
 func (d *Database) GetGameByID(gameID string) (*models.GameHistory, error) {
 	log.Printf("DB: Looking up game %s", gameID)
 	var game models.GameHistory
 
 	err := d.db.QueryRow(`
-		SELECT game_id, crash_point, start_time, end_time, hash
+		SELECT game_id, COALESCE(room_id, ''), round, server_seed_id, COALESCE(client_seed, ''), COALESCE(distribution, ''),
+			crash_point, start_time, end_time, hash
 		FROM games 
-		WHERE game_id = $1::uuid
-	`, gameID).Scan(&game.GameID, &game.CrashPoint, &game.StartTime, &game.EndTime, &game.Hash)
+		WHERE game_id = $1::uuid AND COALESCE(status, 'crashed') = 'crashed'
+	`, gameID).Scan(&game.GameID, &game.RoomID, &game.Round, &game.ServerSeedID, &game.ClientSeed, &game.Distribution,
+		&game.CrashPoint, &game.StartTime, &game.EndTime, &game.Hash)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -257,6 +206,44 @@ func (d *Database) GetGameByID(gameID string) (*models.GameHistory, error) {
 	return &game, nil
 }
 
+// GetGamesByRound returns the recorded rounds of a server seed between from
+// and to inclusive, ordered by round.
+func (d *Database) GetGamesByRound(serverSeedID int, from int64, to int64) ([]models.GameHistory, error) {
+	rows, err := d.db.Query(`
+		SELECT game_id, COALESCE(room_id, ''), round, server_seed_id, COALESCE(client_seed, ''), COALESCE(distribution, ''),
+			crash_point, start_time, end_time, hash
+		FROM games
+		WHERE server_seed_id = $1 AND round BETWEEN $2 AND $3 AND COALESCE(status, 'crashed') = 'crashed'
+		ORDER BY round
+	`, serverSeedID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var games []models.GameHistory
+	for rows.Next() {
+		var g models.GameHistory
+		err := rows.Scan(&g.GameID, &g.RoomID, &g.Round, &g.ServerSeedID, &g.ClientSeed, &g.Distribution,
+			&g.CrashPoint, &g.StartTime, &g.EndTime, &g.Hash)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, g)
+	}
+	return games, rows.Err()
+}
+
+// GetLastGameRound returns the highest hash chain round played with a server
+// seed, or 0 if no round has been played with it yet.
+func (d *Database) GetLastGameRound(serverSeedID int) (int64, error) {
+	var round int64
+	err := d.db.QueryRow(`
+		SELECT COALESCE(MAX(round), 0) FROM games WHERE server_seed_id = $1
+	`, serverSeedID).Scan(&round)
+	return round, err
+}
+
 func (d *Database) Close() error {
 	return d.db.Close()
 }
@@ -271,7 +258,7 @@ func (d *Database) GetPlayerGameHistory(userID string) ([]models.GameHistory, er
 			   b.auto_cashout
 		FROM games g
 		JOIN bets b ON g.game_id = b.game_id
-		WHERE b.user_id = $1
+		WHERE b.user_id = $1 AND b.cancelled_at IS NULL AND COALESCE(g.status, 'crashed') = 'crashed'
 		ORDER BY g.start_time DESC`
 
 	rows, err := d.db.Query(query, userID)
@@ -319,10 +306,14 @@ func (d *Database) GetPlayerGameHistory(userID string) ([]models.GameHistory, er
 	return history, nil
 }
 
+// GetPlayerBetHistory returns the latest bets of a player, each with its
+// cashouts as a JSON array. Rounds still being played have no crash point yet
+// and keep their hash hidden.
 func (d *Database) GetPlayerBetHistory(userID string) (*sql.Rows, error) {
 	query := `
 		SELECT 
 			b.game_id,
+			b.slot,
 			b.amount as bet_amount,
 			b.win_amount,
 			b.cashed_out,
@@ -330,13 +321,19 @@ func (d *Database) GetPlayerBetHistory(userID string) (*sql.Rows, error) {
 			b.auto_cashout,
 			b.created_at,
 			b.cashout_at,
-			g.crash_point,
-			g.hash,
-			g.status
+			COALESCE(g.crash_point, 0),
+			CASE WHEN COALESCE(g.status, 'crashed') = 'crashed' THEN g.hash ELSE '' END,
+			g.status,
+			COALESCE((
+				SELECT json_agg(json_build_object(
+					'stake', c.stake, 'multiplier', c.multiplier,
+					'win_amount', c.win_amount, 'cashout_at', c.cashout_at) ORDER BY c.seq)
+				FROM bet_cashouts c
+				WHERE c.bet_id = b.id), '[]')
 		FROM bets b
 		JOIN games g ON b.game_id = g.game_id
-		WHERE b.user_id = $1
-		ORDER BY b.created_at DESC
+		WHERE b.user_id = $1 AND b.cancelled_at IS NULL
+		ORDER BY b.created_at DESC, b.slot
 		LIMIT 50
 	`
 	return d.db.Query(query, userID)
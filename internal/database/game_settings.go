@@ -0,0 +1,67 @@
+package database
+
+import "encoding/json"
+
+// GetGameSetting decodes the stored setting into dest. It returns
+// sql.ErrNoRows if the setting has never been saved.
+func (d *Database) GetGameSetting(key string, dest interface{}) error {
+	var value []byte
+	err := d.db.QueryRow(`SELECT value FROM game_settings WHERE key = $1`, key).Scan(&value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(value, dest)
+}
+
+func (d *Database) SaveGameSetting(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(`
+        INSERT INTO game_settings (key, value)
+        VALUES ($1, $2)
+        ON CONFLICT (key) DO UPDATE SET
+            value = EXCLUDED.value,
+            updated_at = CURRENT_TIMESTAMP`,
+		key, data)
+	return err
+}
+
+// SaveGameSettingByAdmin saves a setting an admin changed and records the
+// change in the admin actions, in one transaction, so neither is kept
+// without the other.
+func (d *Database) SaveGameSettingByAdmin(adminID int, actionType, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+        INSERT INTO game_settings (key, value)
+        VALUES ($1, $2)
+        ON CONFLICT (key) DO UPDATE SET
+            value = EXCLUDED.value,
+            updated_at = CURRENT_TIMESTAMP`,
+		key, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO admin_actions (admin_id, action_type, target_type, target_id, details)
+        VALUES ($1, $2, 'game', $3, $4)`,
+		adminID, actionType, key, data)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,5 @@
+-- A bet can be cancelled while its round takes bets; the row stays for the
+-- debit and refund that reference it, and the slot is free for a new bet
+ALTER TABLE bets ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP;
+DROP INDEX IF EXISTS idx_bets_game_user_slot;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bets_game_user_slot ON bets(game_id, user_id, slot) WHERE cancelled_at IS NULL;
@@ -0,0 +1,4 @@
+-- A player can hold a bet in each of several slots of a round
+ALTER TABLE bets ADD COLUMN IF NOT EXISTS slot INTEGER NOT NULL DEFAULT 0;
+DROP INDEX IF EXISTS idx_bets_game_user;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bets_game_user_slot ON bets(game_id, user_id, slot);
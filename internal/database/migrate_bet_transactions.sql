@@ -0,0 +1,4 @@
+-- Bets are written together with the debit of their stake, and every debit,
+-- cashout and refund of a bet references its row
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS bet_id INTEGER REFERENCES bets(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_bet_id ON transactions(bet_id);
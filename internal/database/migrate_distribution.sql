@@ -0,0 +1,4 @@
+-- Rounds record the crash distribution they were played with so they can be
+-- verified after the configured distribution changes. Rows without one were
+-- played with the default house edge distribution.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS distribution VARCHAR(64);
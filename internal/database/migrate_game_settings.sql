@@ -0,0 +1,6 @@
+-- Server-wide settings ops can change at runtime, such as the round config.
+CREATE TABLE IF NOT EXISTS game_settings (
+    key VARCHAR(64) PRIMARY KEY,
+    value JSONB NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
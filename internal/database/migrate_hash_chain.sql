@@ -0,0 +1,6 @@
+-- Rounds are now played from a pre-generated hash chain; store the chain
+-- position of every game so it can be verified against the terminating hash.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS round BIGINT;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS status VARCHAR(20);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_games_round ON games(round);
@@ -0,0 +1,19 @@
+-- A bet can be cashed out in parts; every cashout is a line of its bet and
+-- bets.win_amount, cashout_multiplier and cashout_at sum the lines up
+CREATE TABLE IF NOT EXISTS bet_cashouts (
+    id SERIAL PRIMARY KEY,
+    bet_id INTEGER NOT NULL REFERENCES bets(id),
+    seq INTEGER NOT NULL,
+    stake DECIMAL(20,8) NOT NULL,
+    multiplier DECIMAL(10,2) NOT NULL,
+    win_amount DECIMAL(20,8) NOT NULL,
+    cashout_at TIMESTAMP NOT NULL,
+    UNIQUE (bet_id, seq)
+);
+
+-- Bets cashed out before were cashed out whole at once
+INSERT INTO bet_cashouts (bet_id, seq, stake, multiplier, win_amount, cashout_at)
+SELECT id, 1, amount, cashout_multiplier, COALESCE(win_amount, amount * cashout_multiplier), COALESCE(cashout_at, created_at)
+FROM bets
+WHERE cashed_out AND cashout_multiplier IS NOT NULL
+ON CONFLICT (bet_id, seq) DO NOTHING;
@@ -0,0 +1,9 @@
+-- Every room plays its own server seed and hash chain; with a shared chain,
+-- the hash revealed when one room's round crashed gave away the hash of a
+-- round still being played in another room. Seeds played before stay with
+-- the main room, the other rooms get their own seed when they next start.
+ALTER TABLE server_seeds ADD COLUMN IF NOT EXISTS room_id VARCHAR(32) REFERENCES rooms(id);
+UPDATE server_seeds SET room_id = 'main' WHERE room_id IS NULL;
+ALTER TABLE server_seeds ALTER COLUMN room_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_server_seeds_room_id ON server_seeds(room_id, status);
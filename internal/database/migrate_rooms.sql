@@ -0,0 +1,20 @@
+-- Rooms play independent sequences of rounds with their own bet limits and
+-- round config. Rounds recorded before rooms existed were played in main.
+CREATE TABLE IF NOT EXISTS rooms (
+    id VARCHAR(32) PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    min_bet DECIMAL(20,8) NOT NULL DEFAULT 0,
+    max_bet DECIMAL(20,8) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO rooms (id, name, min_bet, max_bet)
+VALUES ('main', 'Main', 0, 1000)
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE games ADD COLUMN IF NOT EXISTS room_id VARCHAR(32) REFERENCES rooms(id);
+UPDATE games SET room_id = 'main' WHERE room_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_games_room_id ON games(room_id, start_time);
+
+-- Round configs are saved per room
+UPDATE game_settings SET key = 'round_config:main' WHERE key = 'round_config';
@@ -0,0 +1,4 @@
+-- A round keeps the payout limits it started with, so a round recovered after
+-- a restart is settled within them too. Zero means no cap.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS max_win DECIMAL(20,8) NOT NULL DEFAULT 0;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS max_payout DECIMAL(20,8) NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+-- Rounds are written when betting opens and bets when they are placed, so a
+-- restart can settle or refund them. A round has no crash point until it
+-- starts and no end time until it is finished.
+ALTER TABLE games ALTER COLUMN crash_point DROP NOT NULL;
+ALTER TABLE games ALTER COLUMN end_time DROP NOT NULL;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS curve VARCHAR(64);
+CREATE INDEX IF NOT EXISTS idx_games_status ON games(status);
+
+-- A player has one bet per round
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bets_game_user ON bets(game_id, user_id);
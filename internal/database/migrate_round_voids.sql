@@ -0,0 +1,12 @@
+-- An admin can void a round: its games row moves to status 'voided', every
+-- stake is refunded and every credited win reversed through transactions of
+-- type 'refund' and 'reversal', and the void is recorded here once
+CREATE TABLE IF NOT EXISTS round_voids (
+    game_id UUID PRIMARY KEY REFERENCES games(game_id),
+    reason TEXT NOT NULL,
+    bets INTEGER NOT NULL,
+    refunded DECIMAL(20,8) NOT NULL,
+    reversed DECIMAL(20,8) NOT NULL,
+    voided_by INTEGER REFERENCES admin_users(id),
+    voided_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,38 @@
+-- Commit-reveal server seeds: every seed generates its own hash chain, so
+-- rounds are numbered per seed.
+CREATE TABLE IF NOT EXISTS server_seeds (
+    id SERIAL PRIMARY KEY,
+    seed VARCHAR(64) NOT NULL,
+    seed_hash VARCHAR(64) NOT NULL,
+    terminating_hash VARCHAR(64) NOT NULL,
+    chain_length BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revealed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS seed_pairs (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    server_seed_id INTEGER NOT NULL REFERENCES server_seeds(id),
+    client_seed VARCHAR(64) NOT NULL,
+    nonce BIGINT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE games ADD COLUMN IF NOT EXISTS server_seed_id INTEGER REFERENCES server_seeds(id);
+ALTER TABLE games ADD COLUMN IF NOT EXISTS client_seed VARCHAR(64);
+
+DROP INDEX IF EXISTS idx_games_round;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_games_seed_round ON games(server_seed_id, round);
+
+ALTER TABLE bets ADD COLUMN IF NOT EXISTS cashout_at TIMESTAMP;
+ALTER TABLE bets ADD COLUMN IF NOT EXISTS auto_cashout DECIMAL(10,2);
+ALTER TABLE bets ADD COLUMN IF NOT EXISTS seed_pair_id INTEGER REFERENCES seed_pairs(id);
+ALTER TABLE bets ADD COLUMN IF NOT EXISTS nonce BIGINT;
+
+-- Admin actions now target seeds, rooms and rounds as well as users
+ALTER TABLE admin_actions ALTER COLUMN target_id TYPE VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_seed_pairs_user_id ON seed_pairs(user_id, server_seed_id);
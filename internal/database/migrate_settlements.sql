@@ -0,0 +1,18 @@
+-- Winners are credited once per round by its settlement, which records the
+-- outcome and marks the round settled
+ALTER TABLE games ADD COLUMN IF NOT EXISTS settled_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS settlements (
+    game_id UUID PRIMARY KEY REFERENCES games(game_id),
+    crash_point DECIMAL(10,2) NOT NULL,
+    bets INTEGER NOT NULL,
+    winners INTEGER NOT NULL,
+    wagered DECIMAL(20,8) NOT NULL,
+    paid DECIMAL(20,8) NOT NULL,
+    attempts INTEGER NOT NULL,
+    settled_at TIMESTAMP NOT NULL
+);
+
+-- Rounds that crashed before settlements existed paid their winners as they
+-- cashed out
+UPDATE games SET settled_at = end_time WHERE status = 'crashed' AND settled_at IS NULL;
@@ -0,0 +1,42 @@
+package database
+
+import "crash-game/internal/models"
+
+func (d *Database) ListRooms() ([]models.Room, error) {
+	rows, err := d.db.Query(`
+        SELECT id, name, min_bet, max_bet, created_at
+        FROM rooms
+        ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []models.Room
+	for rows.Next() {
+		var room models.Room
+		if err := rows.Scan(&room.ID, &room.Name, &room.MinBet, &room.MaxBet, &room.CreatedAt); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, rows.Err()
+}
+
+func (d *Database) CreateRoom(room *models.Room) error {
+	return d.db.QueryRow(`
+        INSERT INTO rooms (id, name, min_bet, max_bet)
+        VALUES ($1, $2, $3, $4)
+        RETURNING created_at`,
+		room.ID, room.Name, room.MinBet, room.MaxBet).Scan(&room.CreatedAt)
+}
+
+// EnsureRoom records a room unless a room with its ID already exists.
+func (d *Database) EnsureRoom(room models.Room) error {
+	_, err := d.db.Exec(`
+        INSERT INTO rooms (id, name, min_bet, max_bet)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (id) DO NOTHING`,
+		room.ID, room.Name, room.MinBet, room.MaxBet)
+	return err
+}
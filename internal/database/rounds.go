@@ -0,0 +1,633 @@
+package database
+
+import (
+	"crash-game/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRoundFinished is returned when recovering a round another process
+// already finished.
+var ErrRoundFinished = errors.New("round already finished")
+
+// ErrRoundSettled is returned when settling a round that was already settled
+// or voided.
+var ErrRoundSettled = errors.New("round already settled")
+
+// ErrRoundNotStarted is returned when settling a round whose crash point was
+// never committed.
+var ErrRoundNotStarted = errors.New("round has not started")
+
+// ErrRoundNotFound is returned when voiding or settling a round that was
+// never recorded.
+var ErrRoundNotFound = errors.New("round not found")
+
+// ErrRoundVoided is returned when voiding a round that was already voided.
+var ErrRoundVoided = errors.New("round already voided")
+
+// ErrRoundRefunded is returned when voiding a round whose bets were already
+// refunded by recovery.
+var ErrRoundRefunded = errors.New("round already refunded")
+
+// ErrInsufficientBalance is returned when a bet is larger than the balance.
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// ErrBetNotCancellable is returned when cancelling a bet that was already
+// cancelled or whose round no longer takes bets.
+var ErrBetNotCancellable = errors.New("bet can no longer be cancelled")
+
+// Cashout is a cashout of part or all of a bet's stake, to record as a line
+// of the bet and pay out.
+type Cashout struct {
+	BetID  int
+	GameID string
+	UserID string
+	// Seq numbers the cashouts of a bet from 1, so recording a cashout
+	// again changes nothing.
+	Seq        int
+	Stake      float64
+	Multiplier float64
+	WinAmount  float64
+	At         time.Time
+	// Final is set on the cashout that closes the bet.
+	Final bool
+}
+
+// CreateRound records a round as soon as betting opens, so its bets can be
+// recovered if the server stops before the round is finished.
+func (d *Database) CreateRound(history *models.GameHistory) error {
+	_, err := d.db.Exec(`
+        INSERT INTO games (game_id, room_id, round, server_seed_id, distribution, curve, max_win, max_payout, start_time, hash, status)
+        VALUES ($1::uuid, NULLIF($2, ''), $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, 'betting')`,
+		history.GameID, history.RoomID, history.Round, history.ServerSeedID, history.Distribution,
+		history.Curve, history.MaxWin, history.MaxPayout, history.StartTime, history.Hash)
+	return err
+}
+
+// StartRound commits the crash point of a round as it starts.
+func (d *Database) StartRound(gameID, clientSeed string, crashPoint float64, startTime time.Time) error {
+	_, err := d.db.Exec(`
+        UPDATE games
+        SET status = 'in_progress', client_seed = $2, crash_point = $3, start_time = $4
+        WHERE game_id = $1::uuid`,
+		gameID, clientSeed, crashPoint, startTime)
+	return err
+}
+
+// PlaceBet debits the stake, consumes the next nonce of the user's seed pair
+// for the round's server seed and records the bet, in one transaction. bet
+// gets the ID of its row and the seed pair it was placed with.
+func (d *Database) PlaceBet(gameID string, serverSeedID int, bet *models.PlayerHistory) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var balance float64
+	err = tx.QueryRow(`
+        SELECT balance FROM users
+        WHERE id = $1
+        FOR UPDATE`, bet.UserID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("user %s not found", bet.UserID)
+	}
+	if err != nil {
+		return err
+	}
+	if balance < bet.BetAmount {
+		return ErrInsufficientBalance
+	}
+
+	pair, err := consumeSeedNonce(tx, bet.UserID, serverSeedID)
+	if err != nil {
+		return err
+	}
+	bet.SeedPairID = pair.ID
+	bet.ClientSeed = pair.ClientSeed
+	bet.Nonce = pair.Nonce
+
+	err = tx.QueryRow(`
+        INSERT INTO bets (game_id, user_id, slot, amount, auto_cashout, seed_pair_id, nonce)
+        VALUES ($1::uuid, $2::uuid, $3, $4, $5, $6, $7)
+        RETURNING id`,
+		gameID, bet.UserID, bet.Slot, bet.BetAmount, bet.AutoCashout, bet.SeedPairID, bet.Nonce).Scan(&bet.BetID)
+	if err != nil {
+		return err
+	}
+
+	newBalance := balance - bet.BetAmount
+	_, err = tx.Exec(`
+        UPDATE users
+        SET balance = $1
+        WHERE id = $2`, newBalance, bet.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err := recordTransaction(tx, bet.UserID, bet.BetAmount, "debit", newBalance, bet.BetID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CancelBet marks a bet of a round still taking bets cancelled and refunds its
+// stake, in one transaction. Cancelled bets take no part in their round.
+func (d *Database) CancelBet(bet models.PlayerHistory, at time.Time) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID string
+	var amount float64
+	err = tx.QueryRow(`
+        UPDATE bets b
+        SET cancelled_at = $2
+        FROM games g
+        WHERE b.id = $1 AND g.game_id = b.game_id AND g.status = 'betting'
+            AND b.cancelled_at IS NULL AND NOT b.cashed_out
+        RETURNING b.user_id, b.amount`, bet.BetID, at).Scan(&userID, &amount)
+	if err == sql.ErrNoRows {
+		return ErrBetNotCancellable
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := creditBalance(tx, userID, amount, "cancel", bet.BetID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordCashout records a cashout on its bet as it happens. The winnings are
+// credited when the round is settled.
+func (d *Database) RecordCashout(cashout Cashout) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := recordCashoutLine(tx, cashout); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordCashoutLine records a cashout as a line of its bet, unless it was
+// recorded before, and sums the lines up on the bet.
+func recordCashoutLine(tx *sql.Tx, cashout Cashout) error {
+	_, err := tx.Exec(`
+        INSERT INTO bet_cashouts (bet_id, seq, stake, multiplier, win_amount, cashout_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (bet_id, seq) DO NOTHING`,
+		cashout.BetID, cashout.Seq, cashout.Stake, cashout.Multiplier, cashout.WinAmount, cashout.At)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+        UPDATE bets b
+        SET cashed_out = b.cashed_out OR $2, win_amount = l.win,
+            cashout_multiplier = ROUND(l.win / b.amount, 2), cashout_at = l.last
+        FROM (
+            SELECT SUM(win_amount) AS win, MAX(cashout_at) AS last
+            FROM bet_cashouts
+            WHERE bet_id = $1
+        ) l
+        WHERE b.id = $1`,
+		cashout.BetID, cashout.Final)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("bet %d of user %s not found", cashout.BetID, cashout.UserID)
+	}
+	return nil
+}
+
+// SettleRound writes the final outcome of a crashed round in one transaction:
+// the cashouts on their bets, the winners' credits, the losing bets and the
+// settlement record, and marks the round settled. It returns ErrRoundSettled
+// if the round was already settled, voided or refunded, and
+// ErrRoundNotStarted if its crash point was never committed.
+func (d *Database) SettleRound(settlement *models.Settlement, cashouts []Cashout) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	var settledAt sql.NullTime
+	err = tx.QueryRow(`
+        SELECT COALESCE(status, ''), settled_at FROM games WHERE game_id = $1::uuid FOR UPDATE`,
+		settlement.GameID).Scan(&status, &settledAt)
+	if err == sql.ErrNoRows {
+		return ErrRoundNotFound
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case settledAt.Valid || status == "voided" || status == "refunded":
+		return ErrRoundSettled
+	case status != "in_progress" && status != "crashed":
+		return fmt.Errorf("%w: round %s is %s", ErrRoundNotStarted, settlement.GameID, status)
+	}
+
+	_, err = tx.Exec(`
+        UPDATE games
+        SET status = 'crashed', end_time = COALESCE(end_time, $2), settled_at = $3
+        WHERE game_id = $1::uuid`,
+		settlement.GameID, settlement.CrashTime, settlement.SettledAt)
+	if err != nil {
+		return err
+	}
+
+	for _, cashout := range cashouts {
+		if err := settleCashout(tx, cashout); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+        UPDATE bets
+        SET win_amount = 0
+        WHERE game_id = $1::uuid AND NOT cashed_out AND cancelled_at IS NULL
+            AND NOT EXISTS (SELECT 1 FROM bet_cashouts WHERE bet_id = bets.id)`, settlement.GameID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO settlements (game_id, crash_point, bets, winners, wagered, paid, attempts, settled_at)
+        VALUES ($1::uuid, $2, $3, $4, $5, $6, $7, $8)`,
+		settlement.GameID, settlement.CrashPoint, settlement.Bets, settlement.Winners,
+		settlement.Wagered, settlement.Paid, settlement.Attempts, settlement.SettledAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// settleCashout writes a cashout on its bet and credits the winnings.
+func settleCashout(tx *sql.Tx, cashout Cashout) error {
+	if err := recordCashoutLine(tx, cashout); err != nil {
+		return err
+	}
+	return creditBalance(tx, cashout.UserID, cashout.WinAmount, "credit", cashout.BetID)
+}
+
+// creditBalance credits a user for a bet and records the transaction.
+func creditBalance(tx *sql.Tx, userID string, amount float64, txType string, betID int) error {
+	var newBalance float64
+	err := tx.QueryRow(`
+        UPDATE users
+        SET balance = balance + $1
+        WHERE id = $2
+        RETURNING balance`, amount, userID).Scan(&newBalance)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("user %s not found", userID)
+	}
+	if err != nil {
+		return err
+	}
+	return recordTransaction(tx, userID, amount, txType, newBalance, betID)
+}
+
+// debitBalance takes an amount back from a user for a bet and records the
+// transaction. Unlike a stake it is taken even if the balance goes negative.
+func debitBalance(tx *sql.Tx, userID string, amount float64, txType string, betID int) error {
+	var newBalance float64
+	err := tx.QueryRow(`
+        UPDATE users
+        SET balance = balance - $1
+        WHERE id = $2
+        RETURNING balance`, amount, userID).Scan(&newBalance)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("user %s not found", userID)
+	}
+	if err != nil {
+		return err
+	}
+	return recordTransaction(tx, userID, amount, txType, newBalance, betID)
+}
+
+// recordTransaction records a balance change, referencing the bet it was
+// made for.
+func recordTransaction(tx *sql.Tx, userID string, amount float64, txType string, balanceAfter float64, betID int) error {
+	_, err := tx.Exec(`
+        INSERT INTO transactions (user_id, amount, type, balance_after, bet_id)
+        VALUES ($1, $2, $3, $4, $5)`,
+		userID, amount, txType, balanceAfter, sql.NullInt64{Int64: int64(betID), Valid: betID != 0})
+	return err
+}
+
+// GetUnfinishedRounds returns the rounds that were never finished or never
+// settled, with their bets, oldest first.
+func (d *Database) GetUnfinishedRounds() ([]models.GameHistory, error) {
+	rows, err := d.db.Query(`
+        SELECT game_id, COALESCE(room_id, ''), round, server_seed_id, COALESCE(client_seed, ''),
+            COALESCE(distribution, ''), COALESCE(curve, ''), max_win, max_payout, COALESCE(crash_point, 0), start_time, end_time, hash, status
+        FROM games
+        WHERE status IN ('betting', 'in_progress') OR (status = 'crashed' AND settled_at IS NULL)
+        ORDER BY start_time`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rounds []models.GameHistory
+	for rows.Next() {
+		var g models.GameHistory
+		var endTime sql.NullTime
+		err := rows.Scan(&g.GameID, &g.RoomID, &g.Round, &g.ServerSeedID, &g.ClientSeed,
+			&g.Distribution, &g.Curve, &g.MaxWin, &g.MaxPayout, &g.CrashPoint, &g.StartTime, &endTime, &g.Hash, &g.Status)
+		if err != nil {
+			return nil, err
+		}
+		g.EndTime = endTime.Time
+		rounds = append(rounds, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range rounds {
+		if rounds[i].Players, err = d.getRoundBets(rounds[i].GameID); err != nil {
+			return nil, err
+		}
+	}
+	return rounds, nil
+}
+
+func (d *Database) getRoundBets(gameID string) ([]models.PlayerHistory, error) {
+	rows, err := d.db.Query(`
+        SELECT id, user_id, slot, amount, COALESCE(win_amount, 0), cashed_out, COALESCE(cashout_multiplier, 0), cashout_at, auto_cashout
+        FROM bets
+        WHERE game_id = $1::uuid AND cancelled_at IS NULL
+        ORDER BY id`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bets []models.PlayerHistory
+	for rows.Next() {
+		var bet models.PlayerHistory
+		var cashoutAt sql.NullTime
+		var autoCashout sql.NullFloat64
+		err := rows.Scan(&bet.BetID, &bet.UserID, &bet.Slot, &bet.BetAmount, &bet.WinAmount, &bet.CashedOut,
+			&bet.CashoutMultiplier, &cashoutAt, &autoCashout)
+		if err != nil {
+			return nil, err
+		}
+		if cashoutAt.Valid {
+			bet.CashoutAt = &cashoutAt.Time
+		}
+		if autoCashout.Valid {
+			bet.AutoCashout = &autoCashout.Float64
+		}
+		bets = append(bets, bet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bets, d.getCashoutLines(gameID, bets)
+}
+
+// getCashoutLines fills in the cashouts of the bets of a round.
+func (d *Database) getCashoutLines(gameID string, bets []models.PlayerHistory) error {
+	index := make(map[int]int, len(bets))
+	for i, bet := range bets {
+		index[bet.BetID] = i
+	}
+
+	rows, err := d.db.Query(`
+        SELECT c.bet_id, c.stake, c.multiplier, c.win_amount, c.cashout_at
+        FROM bet_cashouts c
+        JOIN bets b ON b.id = c.bet_id
+        WHERE b.game_id = $1::uuid
+        ORDER BY c.bet_id, c.seq`, gameID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var betID int
+		var line models.CashoutLine
+		if err := rows.Scan(&betID, &line.Stake, &line.Multiplier, &line.WinAmount, &line.CashoutAt); err != nil {
+			return err
+		}
+		if i, ok := index[betID]; ok {
+			bets[i].Cashouts = append(bets[i].Cashouts, line)
+		}
+	}
+	return rows.Err()
+}
+
+// RefundRound refunds the stake of every bet of an unfinished round and marks
+// the round refunded. Cashouts are only paid when a round is settled, so a
+// bet cashed out in full or in part gets its whole stake back as well.
+// Stakes are recorded as transactions of type refund, as when a round is
+// voided. It returns the amount refunded, or ErrRoundFinished if the round
+// was already finished.
+func (d *Database) RefundRound(gameID string, at time.Time) (float64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := finishRound(tx, gameID, "refunded", at); err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query(`
+        SELECT id, user_id, amount
+        FROM bets
+        WHERE game_id = $1::uuid AND cancelled_at IS NULL`, gameID)
+	if err != nil {
+		return 0, err
+	}
+	var refunds []Cashout
+	for rows.Next() {
+		var refund Cashout
+		if err := rows.Scan(&refund.BetID, &refund.UserID, &refund.WinAmount); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		refunds = append(refunds, refund)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, refund := range refunds {
+		if err := creditBalance(tx, refund.UserID, refund.WinAmount, "refund", refund.BetID); err != nil {
+			return 0, err
+		}
+		total += refund.WinAmount
+	}
+	return total, tx.Commit()
+}
+
+// finishRound moves a round that never crashed to its final status. Only one
+// recovery can succeed for a round.
+func finishRound(tx *sql.Tx, gameID, status string, endTime time.Time) error {
+	result, err := tx.Exec(`
+        UPDATE games
+        SET status = $2, end_time = $3
+        WHERE game_id = $1::uuid AND status IN ('betting', 'in_progress')`,
+		gameID, status, endTime)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrRoundFinished
+	}
+	return nil
+}
+
+// VoidRound voids a round in one transaction: every stake is refunded, every
+// win already credited by the settlement is reversed, the round is marked
+// voided and the void recorded. void carries the round, reason, admin and
+// time and is filled in with the refunds. Voiding a voided round changes
+// nothing and returns ErrRoundVoided with void filled in from the record.
+func (d *Database) VoidRound(void *models.RoundVoid) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the round, so a void and a settlement run one after the other
+	var status sql.NullString
+	var settled bool
+	err = tx.QueryRow(`
+        SELECT COALESCE(room_id, ''), status, settled_at IS NOT NULL
+        FROM games
+        WHERE game_id = $1::uuid
+        FOR UPDATE`, void.GameID).Scan(&void.RoomID, &status, &settled)
+	if err == sql.ErrNoRows {
+		return ErrRoundNotFound
+	}
+	if err != nil {
+		return err
+	}
+	switch status.String {
+	case "voided":
+		if err := getRoundVoid(tx, void); err != nil {
+			return err
+		}
+		return ErrRoundVoided
+	case "refunded":
+		return ErrRoundRefunded
+	}
+
+	rows, err := tx.Query(`
+        SELECT id, user_id, amount, COALESCE(win_amount, 0)
+        FROM bets
+        WHERE game_id = $1::uuid AND cancelled_at IS NULL
+        ORDER BY id`, void.GameID)
+	if err != nil {
+		return err
+	}
+	void.Refunds = nil
+	for rows.Next() {
+		var refund models.BetRefund
+		var win float64
+		if err := rows.Scan(&refund.BetID, &refund.UserID, &refund.Refunded, &win); err != nil {
+			rows.Close()
+			return err
+		}
+		// Wins, partial cashouts included, are only credited when the
+		// round is settled
+		if settled {
+			refund.Reversed = win
+		}
+		void.Refunds = append(void.Refunds, refund)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	void.Bets = len(void.Refunds)
+	void.Refunded, void.Reversed = 0, 0
+	for _, refund := range void.Refunds {
+		if err := creditBalance(tx, refund.UserID, refund.Refunded, "refund", refund.BetID); err != nil {
+			return err
+		}
+		if refund.Reversed > 0 {
+			if err := debitBalance(tx, refund.UserID, refund.Reversed, "reversal", refund.BetID); err != nil {
+				return err
+			}
+		}
+		void.Refunded += refund.Refunded
+		void.Reversed += refund.Reversed
+	}
+
+	_, err = tx.Exec(`
+        UPDATE games
+        SET status = 'voided', end_time = COALESCE(end_time, $2)
+        WHERE game_id = $1::uuid`, void.GameID, void.VoidedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO round_voids (game_id, reason, bets, refunded, reversed, voided_by, voided_at)
+        VALUES ($1::uuid, $2, $3, $4, $5, NULLIF($6, 0), $7)`,
+		void.GameID, void.Reason, void.Bets, void.Refunded, void.Reversed, void.VoidedBy, void.VoidedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// getRoundVoid reads the record of a voided round and its refunds.
+func getRoundVoid(tx *sql.Tx, void *models.RoundVoid) error {
+	err := tx.QueryRow(`
+        SELECT reason, bets, refunded, reversed, COALESCE(voided_by, 0), voided_at
+        FROM round_voids
+        WHERE game_id = $1::uuid`, void.GameID).Scan(&void.Reason, &void.Bets, &void.Refunded,
+		&void.Reversed, &void.VoidedBy, &void.VoidedAt)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`
+        SELECT b.id, b.user_id,
+            COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'refund'), 0),
+            COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'reversal'), 0)
+        FROM bets b
+        JOIN transactions t ON t.bet_id = b.id
+        WHERE b.game_id = $1::uuid AND t.type IN ('refund', 'reversal')
+        GROUP BY b.id, b.user_id
+        ORDER BY b.id`, void.GameID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	void.Refunds = nil
+	for rows.Next() {
+		var refund models.BetRefund
+		if err := rows.Scan(&refund.BetID, &refund.UserID, &refund.Refunded, &refund.Reversed); err != nil {
+			return err
+		}
+		void.Refunds = append(void.Refunds, refund)
+	}
+	return rows.Err()
+}
@@ -22,13 +22,60 @@ CREATE TABLE admin_users (
     last_login TIMESTAMP
 );
 
+-- Rooms play independent sequences of rounds with their own bet limits
+CREATE TABLE rooms (
+    id VARCHAR(32) PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    min_bet DECIMAL(20,8) NOT NULL DEFAULT 0,
+    max_bet DECIMAL(20,8) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO rooms (id, name, min_bet, max_bet) VALUES ('main', 'Main', 0, 1000);
+
+-- Server seeds are committed to by their hash before any round is played
+-- and revealed when they are rotated. Every room plays its own seed, so a
+-- round revealed in one room tells nothing of another room's rounds
+CREATE TABLE server_seeds (
+    id SERIAL PRIMARY KEY,
+    room_id VARCHAR(32) NOT NULL REFERENCES rooms(id),
+    seed VARCHAR(64) NOT NULL,
+    seed_hash VARCHAR(64) NOT NULL,
+    terminating_hash VARCHAR(64) NOT NULL,
+    chain_length BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revealed_at TIMESTAMP
+);
+
 -- Then create tables that reference the base tables
 CREATE TABLE games (
     game_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
-    crash_point DECIMAL(10,2) NOT NULL,
+    server_seed_id INTEGER REFERENCES server_seeds(id),
+    room_id VARCHAR(32) REFERENCES rooms(id),
+    round BIGINT,
+    client_seed VARCHAR(64),
+    distribution VARCHAR(64),
+    curve VARCHAR(64),
+    max_win DECIMAL(20,8) NOT NULL DEFAULT 0, -- payout limits of the round, 0 for none
+    max_payout DECIMAL(20,8) NOT NULL DEFAULT 0,
+    crash_point DECIMAL(10,2), -- committed when the round starts
     start_time TIMESTAMP NOT NULL,
-    end_time TIMESTAMP NOT NULL,
-    hash VARCHAR(64) NOT NULL
+    end_time TIMESTAMP,
+    settled_at TIMESTAMP, -- set once the winners are credited
+    hash VARCHAR(64) NOT NULL,
+    status VARCHAR(20),
+    UNIQUE (server_seed_id, round)
+);
+
+CREATE TABLE seed_pairs (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    server_seed_id INTEGER NOT NULL REFERENCES server_seeds(id),
+    client_seed VARCHAR(64) NOT NULL,
+    nonce BIGINT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE TABLE payment_methods (
@@ -47,19 +94,58 @@ CREATE TABLE bets (
     id SERIAL PRIMARY KEY,
     game_id UUID REFERENCES games(game_id),
     user_id UUID REFERENCES users(id),
+    slot INTEGER NOT NULL DEFAULT 0,
     amount DECIMAL(20,8) NOT NULL,
     cashed_out BOOLEAN DEFAULT FALSE,
     cashout_multiplier DECIMAL(10,2),
     win_amount DECIMAL(20,8),
+    cashout_at TIMESTAMP,
+    auto_cashout DECIMAL(10,2),
+    seed_pair_id INTEGER REFERENCES seed_pairs(id),
+    nonce BIGINT,
+    cancelled_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE bet_cashouts (
+    id SERIAL PRIMARY KEY,
+    bet_id INTEGER NOT NULL REFERENCES bets(id),
+    seq INTEGER NOT NULL,
+    stake DECIMAL(20,8) NOT NULL,
+    multiplier DECIMAL(10,2) NOT NULL,
+    win_amount DECIMAL(20,8) NOT NULL,
+    cashout_at TIMESTAMP NOT NULL,
+    UNIQUE (bet_id, seq)
+);
+
+CREATE TABLE settlements (
+    game_id UUID PRIMARY KEY REFERENCES games(game_id),
+    crash_point DECIMAL(10,2) NOT NULL,
+    bets INTEGER NOT NULL,
+    winners INTEGER NOT NULL,
+    wagered DECIMAL(20,8) NOT NULL,
+    paid DECIMAL(20,8) NOT NULL,
+    attempts INTEGER NOT NULL,
+    settled_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE round_voids (
+    game_id UUID PRIMARY KEY REFERENCES games(game_id),
+    reason TEXT NOT NULL,
+    bets INTEGER NOT NULL,
+    refunded DECIMAL(20,8) NOT NULL,
+    reversed DECIMAL(20,8) NOT NULL,
+    voided_by INTEGER REFERENCES admin_users(id),
+    voided_at TIMESTAMP NOT NULL
+);
+
 CREATE TABLE transactions (
     id SERIAL PRIMARY KEY,
     user_id UUID NOT NULL,
     amount DECIMAL(20,8) NOT NULL,
     type VARCHAR(10) NOT NULL,
     balance_after DECIMAL(20,8) NOT NULL,
+    bet_id INTEGER REFERENCES bets(id),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id)
 );
@@ -117,11 +203,17 @@ CREATE TABLE admin_actions (
     admin_id INTEGER REFERENCES admin_users(id),
     action_type VARCHAR(50) NOT NULL,
     target_type VARCHAR(50) NOT NULL,
-    target_id UUID NOT NULL,
+    target_id VARCHAR(64) NOT NULL,
     details JSONB,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE game_settings (
+    key VARCHAR(64) PRIMARY KEY,
+    value JSONB NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
 CREATE TABLE notifications (
     id SERIAL PRIMARY KEY,
     type VARCHAR(50) NOT NULL,
@@ -134,8 +226,14 @@ CREATE TABLE notifications (
 -- Create indexes
 CREATE INDEX idx_bets_user_id ON bets(user_id);
 CREATE INDEX idx_bets_game_id ON bets(game_id);
+CREATE UNIQUE INDEX idx_bets_game_user_slot ON bets(game_id, user_id, slot) WHERE cancelled_at IS NULL;
+CREATE INDEX idx_games_status ON games(status);
+CREATE INDEX idx_games_room_id ON games(room_id, start_time);
 CREATE INDEX idx_transactions_user_id ON transactions(user_id);
 CREATE INDEX idx_transactions_created_at ON transactions(created_at);
+CREATE INDEX idx_transactions_bet_id ON transactions(bet_id);
 CREATE INDEX idx_withdrawals_status ON withdrawals(status);
 CREATE INDEX idx_deposits_user_id ON deposits(user_id);
-CREATE INDEX idx_payment_methods_user_id ON payment_methods(user_id);
\ No newline at end of file
+CREATE INDEX idx_payment_methods_user_id ON payment_methods(user_id);
+CREATE INDEX idx_seed_pairs_user_id ON seed_pairs(user_id, server_seed_id);
+CREATE INDEX idx_server_seeds_room_id ON server_seeds(room_id, status);
\ No newline at end of file
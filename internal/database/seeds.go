@@ -0,0 +1,242 @@
+package database
+
+import (
+	"crash-game/internal/game"
+	"crash-game/internal/models"
+	"database/sql"
+	"errors"
+)
+
+func (d *Database) CreateServerSeed(seed *models.ServerSeed) error {
+	return d.db.QueryRow(`
+        INSERT INTO server_seeds (room_id, seed, seed_hash, terminating_hash, chain_length, status)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at`,
+		seed.RoomID, seed.Seed, seed.SeedHash, seed.TerminatingHash, seed.ChainLength, seed.Status).
+		Scan(&seed.ID, &seed.CreatedAt)
+}
+
+func (d *Database) GetServerSeed(id int) (*models.ServerSeed, error) {
+	return d.getServerSeed(`WHERE id = $1`, id)
+}
+
+// GetActiveServerSeed returns the seed a room's rounds are currently played
+// with, or sql.ErrNoRows if none has been created yet.
+func (d *Database) GetActiveServerSeed(roomID string) (*models.ServerSeed, error) {
+	return d.getServerSeed(`WHERE room_id = $1 AND status = $2 ORDER BY id DESC LIMIT 1`, roomID, "active")
+}
+
+// GetPendingServerSeed returns the seed waiting to replace a room's active
+// one at its next round boundary, or sql.ErrNoRows if no rotation is
+// scheduled.
+func (d *Database) GetPendingServerSeed(roomID string) (*models.ServerSeed, error) {
+	return d.getServerSeed(`WHERE room_id = $1 AND status = $2 ORDER BY id DESC LIMIT 1`, roomID, "pending")
+}
+
+func (d *Database) getServerSeed(where string, args ...interface{}) (*models.ServerSeed, error) {
+	var seed models.ServerSeed
+	var revealedAt sql.NullTime
+
+	err := d.db.QueryRow(`
+        SELECT id, room_id, seed, seed_hash, terminating_hash, chain_length, status, created_at, revealed_at
+        FROM server_seeds `+where, args...).Scan(
+		&seed.ID, &seed.RoomID, &seed.Seed, &seed.SeedHash, &seed.TerminatingHash,
+		&seed.ChainLength, &seed.Status, &seed.CreatedAt, &revealedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if revealedAt.Valid {
+		seed.RevealedAt = &revealedAt.Time
+	}
+	return &seed, nil
+}
+
+func (d *Database) ListServerSeeds() ([]models.ServerSeed, error) {
+	rows, err := d.db.Query(`
+        SELECT id, room_id, seed, seed_hash, terminating_hash, chain_length, status, created_at, revealed_at
+        FROM server_seeds
+        ORDER BY id DESC
+        LIMIT 50`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seeds []models.ServerSeed
+	for rows.Next() {
+		var seed models.ServerSeed
+		var revealedAt sql.NullTime
+		err := rows.Scan(&seed.ID, &seed.RoomID, &seed.Seed, &seed.SeedHash, &seed.TerminatingHash,
+			&seed.ChainLength, &seed.Status, &seed.CreatedAt, &revealedAt)
+		if err != nil {
+			return nil, err
+		}
+		if revealedAt.Valid {
+			seed.RevealedAt = &revealedAt.Time
+		}
+		seeds = append(seeds, seed)
+	}
+	return seeds, nil
+}
+
+// ActivateServerSeed reveals the previous seed and makes the next one active.
+func (d *Database) ActivateServerSeed(nextID int, previousID int) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+        UPDATE server_seeds
+        SET status = 'revealed', revealed_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status = 'active'`, previousID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+        UPDATE server_seeds SET status = 'active'
+        WHERE id = $1 AND status = 'pending'`, nextID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.New("pending server seed not found")
+	}
+
+	return tx.Commit()
+}
+
+// GetSeedPair returns the user's active seed pair for a server seed, creating
+// it if the user has not bet with that seed yet.
+func (d *Database) GetSeedPair(userID string, serverSeedID int) (*models.SeedPair, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	pair, err := ensureSeedPair(tx, userID, serverSeedID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, tx.Commit()
+}
+
+// consumeSeedNonce consumes the next nonce of the user's active seed pair.
+// The returned pair carries the nonce used by the bet.
+func consumeSeedNonce(tx *sql.Tx, userID string, serverSeedID int) (*models.SeedPair, error) {
+	pair, err := ensureSeedPair(tx, userID, serverSeedID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`UPDATE seed_pairs SET nonce = nonce + 1 WHERE id = $1`, pair.ID)
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// SetClientSeed starts a new seed pair with the given client seed. Nonces
+// restart from zero so every (server seed, client seed, nonce) is unique.
+func (d *Database) SetClientSeed(userID string, serverSeedID int, clientSeed string) (*models.SeedPair, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+        UPDATE seed_pairs SET active = false
+        WHERE user_id = $1 AND server_seed_id = $2 AND active`,
+		userID, serverSeedID)
+	if err != nil {
+		return nil, err
+	}
+
+	pair, err := insertSeedPair(tx, userID, serverSeedID, clientSeed)
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, tx.Commit()
+}
+
+func ensureSeedPair(tx *sql.Tx, userID string, serverSeedID int) (*models.SeedPair, error) {
+	pair := &models.SeedPair{UserID: userID, ServerSeedID: serverSeedID}
+
+	err := tx.QueryRow(`
+        SELECT id, client_seed, nonce, created_at
+        FROM seed_pairs
+        WHERE user_id = $1 AND server_seed_id = $2 AND active
+        FOR UPDATE`,
+		userID, serverSeedID).Scan(&pair.ID, &pair.ClientSeed, &pair.Nonce, &pair.CreatedAt)
+	if err == nil {
+		return pair, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// Carry the user's client seed over from the previous server seed
+	var clientSeed string
+	err = tx.QueryRow(`
+        SELECT client_seed FROM seed_pairs
+        WHERE user_id = $1
+        ORDER BY id DESC
+        LIMIT 1`, userID).Scan(&clientSeed)
+	if err == sql.ErrNoRows {
+		clientSeed, err = game.GenerateClientSeed()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return insertSeedPair(tx, userID, serverSeedID, clientSeed)
+}
+
+func insertSeedPair(tx *sql.Tx, userID string, serverSeedID int, clientSeed string) (*models.SeedPair, error) {
+	pair := &models.SeedPair{
+		UserID:       userID,
+		ServerSeedID: serverSeedID,
+		ClientSeed:   clientSeed,
+	}
+
+	err := tx.QueryRow(`
+        INSERT INTO seed_pairs (user_id, server_seed_id, client_seed, nonce, active)
+        VALUES ($1, $2, $3, 0, true)
+        RETURNING id, created_at`,
+		userID, serverSeedID, clientSeed).Scan(&pair.ID, &pair.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// GetRoundClientSeeds returns the seed pair contribution of every bet of a
+// round, from which the round client seed is combined.
+func (d *Database) GetRoundClientSeeds(gameID string) ([]game.ClientSeedEntry, error) {
+	rows, err := d.db.Query(`
+        SELECT b.user_id, sp.client_seed, b.nonce
+        FROM bets b
+        JOIN seed_pairs sp ON sp.id = b.seed_pair_id
+        WHERE b.game_id = $1::uuid AND b.cancelled_at IS NULL`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]game.ClientSeedEntry, 0)
+	for rows.Next() {
+		var e game.ClientSeedEntry
+		if err := rows.Scan(&e.UserID, &e.ClientSeed, &e.Nonce); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -31,7 +31,7 @@ func (d *Database) GetLeaderboard(timeFrame string) ([]UserStats, error) {
 			MAX(win_amount) as biggest_win,
 			MAX(CASE WHEN cashed_out THEN cashout_multiplier ELSE 0 END) as highest_crash
 		FROM bets
-		WHERE 1=1 ` + timeFilter + `
+		WHERE cancelled_at IS NULL ` + timeFilter + `
 		GROUP BY user_id
 		ORDER BY total_won DESC
 		LIMIT 100
@@ -92,7 +92,7 @@ func (d *Database) GetUserProfile(userID string) (*UserProfile, error) {
             COUNT(DISTINCT b.game_id) as games_played,
             u.created_at
         FROM users u
-        LEFT JOIN bets b ON u.id = b.user_id
+        LEFT JOIN bets b ON u.id = b.user_id AND b.cancelled_at IS NULL
         WHERE u.id = $1
         GROUP BY u.id`, userID).Scan(
 		&profile.ID,
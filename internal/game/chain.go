@@ -0,0 +1,121 @@
+package game
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+)
+
+// DefaultChainLength is the number of rounds a freshly generated chain can serve.
+const DefaultChainLength = 100000
+
+var (
+	ErrChainExhausted = errors.New("hash chain exhausted")
+	ErrInvalidRound   = errors.New("round is outside the hash chain")
+)
+
+// HashChain is a reverse SHA-256 hash chain. It is generated backwards from a
+// secret seed: the last hash is sha256(seed) and every earlier hash is the
+// sha256 of the hex string of the hash after it. The first hash, the
+// terminating hash, is published before any round is played.
+//
+// Round n is played with hashes[n], so hashing a revealed round hash n times
+// must yield the terminating hash, and hashing it once yields round n-1.
+type HashChain struct {
+	mu     sync.Mutex
+	hashes []string
+	next   int64
+}
+
+func NewHashChain(seed string, length int64) *HashChain {
+	hashes := make([]string, length+1)
+	hashes[length] = hashHex(seed)
+	for i := length - 1; i >= 0; i-- {
+		hashes[i] = hashHex(hashes[i+1])
+	}
+
+	return &HashChain{
+		hashes: hashes,
+		next:   1,
+	}
+}
+
+func hashHex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// TerminatingHash returns the public commitment for the whole chain.
+func (c *HashChain) TerminatingHash() string {
+	return c.hashes[0]
+}
+
+// Length returns the number of playable rounds in the chain.
+func (c *HashChain) Length() int64 {
+	return int64(len(c.hashes) - 1)
+}
+
+// Hash returns the hash used by the given round.
+func (c *HashChain) Hash(round int64) (string, error) {
+	if round < 1 || round > c.Length() {
+		return "", ErrInvalidRound
+	}
+	return c.hashes[round], nil
+}
+
+// Next consumes the next unused hash and returns it with its round number.
+func (c *HashChain) Next() (int64, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.next > c.Length() {
+		return 0, "", ErrChainExhausted
+	}
+
+	round := c.next
+	c.next++
+	return round, c.hashes[round], nil
+}
+
+// SetNextRound sets the round that the next call to Next will return. It is used to
+// resume a chain after a restart without replaying hashes.
+func (c *HashChain) SetNextRound(round int64) error {
+	if round < 1 || round > c.Length()+1 {
+		return ErrInvalidRound
+	}
+
+	c.mu.Lock()
+	c.next = round
+	c.mu.Unlock()
+	return nil
+}
+
+// NextRound returns the round number the next call to Next will return.
+func (c *HashChain) NextRound() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.next
+}
+
+// VerifyChainHash reports whether hash is the hash of the given round in the
+// chain committed to by terminatingHash.
+func VerifyChainHash(hash string, round int64, terminatingHash string) bool {
+	return VerifyChainLink(hash, round, terminatingHash, 0)
+}
+
+// VerifyChainLink reports whether hash is the hash of the given round in a
+// chain where anchorHash is already known to be the hash of anchorRound. The
+// terminating hash is the anchor of round 0. Checking a range of rounds from
+// the lowest up, each against the last verified one, hashes every link once.
+func VerifyChainLink(hash string, round int64, anchorHash string, anchorRound int64) bool {
+	if round <= anchorRound || anchorRound < 0 {
+		return false
+	}
+
+	h := hash
+	for i := anchorRound; i < round; i++ {
+		h = hashHex(h)
+	}
+	return h == anchorHash
+}
@@ -0,0 +1,178 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// DefaultCurveSpec is the growth curve rounds have always been played with.
+const DefaultCurveSpec = "exponential:0.1"
+
+var ErrInvalidCurve = errors.New("invalid growth curve")
+
+// GrowthCurve maps the time a round has been running to its multiplier. Curves
+// start at 1 and increase strictly, so every multiplier is reached at exactly
+// one instant.
+type GrowthCurve interface {
+	Multiplier(elapsed time.Duration) float64
+	// TimeFor returns the instant the curve reaches multiplier.
+	TimeFor(multiplier float64) time.Duration
+	// Spec returns the configuration string the curve is parsed from.
+	Spec() string
+}
+
+// ExponentialCurve grows as e^(Rate * seconds).
+type ExponentialCurve struct {
+	Rate float64
+}
+
+func (c ExponentialCurve) Multiplier(elapsed time.Duration) float64 {
+	return math.Exp(c.Rate * elapsed.Seconds())
+}
+
+func (c ExponentialCurve) TimeFor(multiplier float64) time.Duration {
+	if multiplier <= 1 {
+		return 0
+	}
+	return seconds(math.Log(multiplier) / c.Rate)
+}
+
+func (c ExponentialCurve) Spec() string {
+	return "exponential:" + formatFloat(c.Rate)
+}
+
+// CurveSegment grows exponentially at Rate until Until, measured from the
+// start of the round. The last segment of a curve has no end.
+type CurveSegment struct {
+	Rate  float64
+	Until time.Duration
+}
+
+// PiecewiseCurve chains exponential segments, each continuing from the
+// multiplier the previous one ended at. It lets early multipliers rise at a
+// different pace than later ones.
+type PiecewiseCurve struct {
+	Segments []CurveSegment
+}
+
+func (c PiecewiseCurve) Multiplier(elapsed time.Duration) float64 {
+	multiplier, start := 1.0, time.Duration(0)
+	for i, seg := range c.Segments {
+		if i == len(c.Segments)-1 || elapsed <= seg.Until {
+			return multiplier * math.Exp(seg.Rate*(elapsed-start).Seconds())
+		}
+		multiplier *= math.Exp(seg.Rate * (seg.Until - start).Seconds())
+		start = seg.Until
+	}
+	return multiplier
+}
+
+func (c PiecewiseCurve) TimeFor(target float64) time.Duration {
+	if target <= 1 {
+		return 0
+	}
+
+	multiplier, start := 1.0, time.Duration(0)
+	for i, seg := range c.Segments {
+		if i < len(c.Segments)-1 {
+			end := multiplier * math.Exp(seg.Rate*(seg.Until-start).Seconds())
+			if target > end {
+				multiplier, start = end, seg.Until
+				continue
+			}
+		}
+		return start + seconds(math.Log(target/multiplier)/seg.Rate)
+	}
+	return start
+}
+
+func (c PiecewiseCurve) Spec() string {
+	parts := make([]string, 0, len(c.Segments))
+	for i, seg := range c.Segments {
+		part := formatFloat(seg.Rate)
+		if i < len(c.Segments)-1 {
+			part += "@" + seg.Until.String()
+		}
+		parts = append(parts, part)
+	}
+	return "piecewise:" + strings.Join(parts, ",")
+}
+
+func DefaultCurve() GrowthCurve {
+	return ExponentialCurve{Rate: 0.1}
+}
+
+// ParseCurve builds a growth curve from its spec:
+//
+//	exponential:<rate>                 e.g. exponential:0.1
+//	piecewise:<rate>@<until>,...,<rate> e.g. piecewise:0.05@10s,0.1
+//
+// Rates are per second. An empty spec selects the default curve.
+func ParseCurve(spec string) (GrowthCurve, error) {
+	if spec == "" {
+		return DefaultCurve(), nil
+	}
+
+	kind, rest, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "exponential":
+		rate, err := parseRate(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidCurve, spec, err)
+		}
+		return ExponentialCurve{Rate: rate}, nil
+
+	case "piecewise":
+		var curve PiecewiseCurve
+		parts := strings.Split(rest, ",")
+		for i, part := range parts {
+			rateValue, untilValue, bounded := strings.Cut(part, "@")
+			if bounded == (i == len(parts)-1) {
+				return nil, fmt.Errorf("%w: %q: every segment but the last needs an end", ErrInvalidCurve, spec)
+			}
+
+			rate, err := parseRate(rateValue)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q: %v", ErrInvalidCurve, spec, err)
+			}
+
+			seg := CurveSegment{Rate: rate}
+			if bounded {
+				seg.Until, err = time.ParseDuration(untilValue)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %q: %v", ErrInvalidCurve, spec, err)
+				}
+				if n := len(curve.Segments); seg.Until <= 0 || (n > 0 && seg.Until <= curve.Segments[n-1].Until) {
+					return nil, fmt.Errorf("%w: %q: segment ends must increase", ErrInvalidCurve, spec)
+				}
+			}
+			curve.Segments = append(curve.Segments, seg)
+		}
+		return curve, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidCurve, kind)
+}
+
+// minRate is the slowest growth a curve may have, per second. Even a crash
+// point of 1e16x is reached within about a year at this rate, so TimeFor
+// never overflows a time.Duration.
+const minRate = 1e-6
+
+func parseRate(value string) (float64, error) {
+	rate, err := parseFinite(value)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("rate must be a positive number, got %q", value)
+	}
+	if rate < minRate {
+		return 0, fmt.Errorf("rate must be at least %g, got %q", minRate, value)
+	}
+	return rate, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,188 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultHouseEdge is the share of every bet the house keeps on average.
+const DefaultHouseEdge = 0.02
+
+// DefaultDistributionSpec is used when no distribution is configured and for
+// rounds recorded before distributions were configurable.
+const DefaultDistributionSpec = "house_edge:0.02"
+
+var ErrInvalidDistribution = errors.New("invalid crash distribution")
+
+// CrashDistribution maps a round digest to a crash point. The game loop, the
+// verifiers and the tests all derive crash points through it, so the number a
+// player sees and the number that is verified are always the same.
+type CrashDistribution interface {
+	CrashPoint(digest string) float64
+	// Survival returns the theoretical probability that a round reaches the
+	// given multiplier, that is P(crash point >= multiplier).
+	Survival(multiplier float64) float64
+	// Spec returns the configuration string the distribution is parsed from.
+	// Rounds record it so they can be verified with the same distribution.
+	Spec() string
+}
+
+// HouseEdgeDistribution is the standard crash distribution:
+//
+//	r     = first 52 bits of the digest (13 hex characters)
+//	X     = r / 2^52, uniform in [0, 1)
+//	crash = floor(100 * (1 - HouseEdge) / (1 - X)) / 100, at least 1.00
+//
+// The chance of reaching multiplier m is (1 - HouseEdge) / m, so a player
+// cashing out at any target expects to get back 1 - HouseEdge of the bet.
+type HouseEdgeDistribution struct {
+	HouseEdge float64
+}
+
+func (d HouseEdgeDistribution) CrashPoint(digest string) float64 {
+	if len(digest) < 13 {
+		return 1.0
+	}
+
+	r, err := strconv.ParseUint(digest[:13], 16, 64)
+	if err != nil {
+		return 1.0
+	}
+
+	x := float64(r) / float64(uint64(1)<<52)
+	result := math.Floor(100*(1-d.HouseEdge)/(1-x)) / 100
+
+	return math.Max(1.0, result)
+}
+
+func (d HouseEdgeDistribution) Survival(multiplier float64) float64 {
+	if multiplier <= 1 {
+		return 1
+	}
+	return math.Min(1, (1-d.HouseEdge)/multiplier)
+}
+
+func (d HouseEdgeDistribution) Spec() string {
+	return "house_edge:" + formatFloat(d.HouseEdge)
+}
+
+// CappedDistribution limits the crash points of another distribution.
+type CappedDistribution struct {
+	Base CrashDistribution
+	Cap  float64
+}
+
+func (d CappedDistribution) CrashPoint(digest string) float64 {
+	return math.Min(d.Base.CrashPoint(digest), d.Cap)
+}
+
+func (d CappedDistribution) Survival(multiplier float64) float64 {
+	if multiplier > d.Cap {
+		return 0
+	}
+	return d.Base.Survival(multiplier)
+}
+
+func (d CappedDistribution) Spec() string {
+	return "capped:" + formatFloat(d.Cap) + ":" + d.Base.Spec()
+}
+
+// FixedDistribution crashes every round at the same point. It is meant for
+// tests and must not be used for real play.
+type FixedDistribution struct {
+	Point float64
+}
+
+func (d FixedDistribution) CrashPoint(digest string) float64 {
+	return d.Point
+}
+
+func (d FixedDistribution) Survival(multiplier float64) float64 {
+	if multiplier > d.Point {
+		return 0
+	}
+	return 1
+}
+
+func (d FixedDistribution) Spec() string {
+	return "fixed:" + formatFloat(d.Point)
+}
+
+func DefaultDistribution() CrashDistribution {
+	return HouseEdgeDistribution{HouseEdge: DefaultHouseEdge}
+}
+
+// ParseDistribution builds a distribution from its spec:
+//
+//	house_edge:<edge>          e.g. house_edge:0.02
+//	capped:<cap>:<base spec>   e.g. capped:1000:house_edge:0.02
+//	fixed:<point>              e.g. fixed:2.5
+//
+// An empty spec selects the default distribution.
+func ParseDistribution(spec string) (CrashDistribution, error) {
+	if spec == "" {
+		return DefaultDistribution(), nil
+	}
+
+	kind, rest, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "house_edge":
+		edge, err := parseFinite(rest)
+		if err != nil || edge < 0 || edge >= 1 {
+			return nil, fmt.Errorf("%w: house edge must be in [0, 1): %q", ErrInvalidDistribution, spec)
+		}
+		return HouseEdgeDistribution{HouseEdge: edge}, nil
+
+	case "capped":
+		capValue, baseSpec, _ := strings.Cut(rest, ":")
+		capPoint, err := parseFinite(capValue)
+		if err != nil || capPoint < 1 {
+			return nil, fmt.Errorf("%w: cap must be at least 1: %q", ErrInvalidDistribution, spec)
+		}
+		base, err := ParseDistribution(baseSpec)
+		if err != nil {
+			return nil, err
+		}
+		return CappedDistribution{Base: base, Cap: capPoint}, nil
+
+	case "fixed":
+		point, err := parseFinite(rest)
+		if err != nil || point < 1 {
+			return nil, fmt.Errorf("%w: fixed point must be at least 1: %q", ErrInvalidDistribution, spec)
+		}
+		return FixedDistribution{Point: point}, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidDistribution, kind)
+}
+
+// PlayableDistribution reports whether a distribution may be used for real
+// play; a fixed crash point, capped or not, is only for tests.
+func PlayableDistribution(d CrashDistribution) bool {
+	switch d := d.(type) {
+	case FixedDistribution:
+		return false
+	case CappedDistribution:
+		return PlayableDistribution(d.Base)
+	}
+	return true
+}
+
+// parseFinite parses a number, rejecting NaN and infinities.
+func parseFinite(value string) (float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("%q is not a finite number", value)
+	}
+	return f, nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,111 @@
+package game
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const maxClientSeedLength = 64
+
+var (
+	ErrInvalidClientSeed = errors.New("client seed must be 1-64 letters, digits, '-' or '_'")
+
+	clientSeedPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// GenerateServerSeed returns a new secret server seed.
+func GenerateServerSeed() (string, error) {
+	return randomHex(32)
+}
+
+// GenerateClientSeed returns a random client seed for players that have not
+// chosen their own.
+func GenerateClientSeed() (string, error) {
+	return randomHex(16)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func ValidateClientSeed(clientSeed string) error {
+	if len(clientSeed) == 0 || len(clientSeed) > maxClientSeedLength || !clientSeedPattern.MatchString(clientSeed) {
+		return ErrInvalidClientSeed
+	}
+	return nil
+}
+
+// HashServerSeed returns the commitment published for a server seed before
+// any round is played with it.
+func HashServerSeed(serverSeed string) string {
+	return hashHex(serverSeed)
+}
+
+// ChainSeed derives the hash chain seed from a server seed. The chain is not
+// built from the server seed directly, otherwise the published commitment
+// sha256(serverSeed) would be the last hash of the chain and reveal every round.
+func ChainSeed(serverSeed string) string {
+	return hmacHex(serverSeed, "hash-chain")
+}
+
+// NewSeedChain builds the hash chain played with a server seed. Once the seed
+// is revealed anyone can rebuild the chain and check every round played on it.
+func NewSeedChain(serverSeed string, length int64) *HashChain {
+	return NewHashChain(ChainSeed(serverSeed), length)
+}
+
+// ClientSeedEntry is one player's contribution to a round: the client seed of
+// their active seed pair and the nonce their bet consumed.
+type ClientSeedEntry struct {
+	UserID     string `json:"userId"`
+	ClientSeed string `json:"clientSeed"`
+	Nonce      int64  `json:"nonce"`
+}
+
+// CombineClientSeeds returns the client seed of a round. Every bettor's
+// "userId:clientSeed:nonce" is sorted, joined with newlines and hashed, so the
+// result does not depend on the order bets arrived in. A round without
+// entries has an empty client seed.
+func CombineClientSeeds(entries []ClientSeedEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, fmt.Sprintf("%s:%s:%d", e.UserID, e.ClientSeed, e.Nonce))
+	}
+	sort.Strings(lines)
+
+	return hashHex(strings.Join(lines, "\n"))
+}
+
+// RoundDigest mixes a round's client seed into its chain hash. The chain hash
+// is committed before any bet is placed and the client seed is only known
+// when betting closes, so players cannot pick a round's outcome. The server
+// can: it knows every chain hash in advance and may bet from an account of
+// its own with a client seed of its choosing, so the client seed only adds
+// player entropy and does not stop the server from grinding the digest.
+func RoundDigest(hash string, clientSeed string) string {
+	if clientSeed == "" {
+		return hash
+	}
+	return hmacHex(hash, clientSeed)
+}
+
+func hmacHex(key string, message string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(message))
+	return hex.EncodeToString(h.Sum(nil))
+}
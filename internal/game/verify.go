@@ -0,0 +1,129 @@
+package game
+
+import "sort"
+
+const (
+	ChainOK        = "ok"
+	ChainMismatch  = "mismatch"
+	ChainUnchecked = "unchecked"
+)
+
+// RoundRecord is a played round as recorded or exported. A zero CrashPoint
+// means no crash point was recorded and only the chain is checked.
+type RoundRecord struct {
+	GameID       string
+	Round        int64
+	Hash         string
+	ClientSeed   string
+	Distribution string
+	CrashPoint   float64
+}
+
+type RoundCheck struct {
+	GameID             string  `json:"gameId,omitempty"`
+	Round              int64   `json:"round"`
+	Hash               string  `json:"hash"`
+	ClientSeed         string  `json:"clientSeed"`
+	Distribution       string  `json:"distribution"`
+	CrashPoint         float64 `json:"crashPoint"`
+	RecordedCrashPoint float64 `json:"recordedCrashPoint,omitempty"`
+	Chain              string  `json:"chain"`
+	Pass               bool    `json:"pass"`
+	Error              string  `json:"error,omitempty"`
+}
+
+// RoundVerifier checks recorded rounds of one server seed. With the revealed
+// chain every hash is compared directly; with only the terminating hash the
+// rounds are checked link by link from the lowest up.
+type RoundVerifier struct {
+	Chain           *HashChain
+	TerminatingHash string
+	// Distribution is used for records that do not name their own.
+	Distribution CrashDistribution
+}
+
+// Verify checks the records and returns one result per record, ordered by
+// round.
+func (v RoundVerifier) Verify(records []RoundRecord) []RoundCheck {
+	sorted := make([]RoundRecord, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Round < sorted[j].Round })
+
+	defaultDist := v.Distribution
+	if defaultDist == nil {
+		defaultDist = DefaultDistribution()
+	}
+
+	terminatingHash := v.TerminatingHash
+	if v.Chain != nil {
+		terminatingHash = v.Chain.TerminatingHash()
+	}
+	anchorRound, anchorHash := int64(0), terminatingHash
+
+	checks := make([]RoundCheck, 0, len(sorted))
+	for _, rec := range sorted {
+		check := RoundCheck{
+			GameID:             rec.GameID,
+			Round:              rec.Round,
+			Hash:               rec.Hash,
+			ClientSeed:         rec.ClientSeed,
+			RecordedCrashPoint: rec.CrashPoint,
+			Chain:              ChainUnchecked,
+		}
+
+		switch {
+		case v.Chain != nil:
+			expected, err := v.Chain.Hash(rec.Round)
+			if err != nil {
+				check.Chain = ChainMismatch
+				check.Error = "round is outside the chain"
+				break
+			}
+			if check.Hash == "" {
+				check.Hash = expected
+			}
+			check.Chain = ChainOK
+			if check.Hash != expected {
+				check.Chain = ChainMismatch
+			}
+
+		case terminatingHash != "":
+			check.Chain = ChainMismatch
+			if VerifyChainLink(rec.Hash, rec.Round, anchorHash, anchorRound) {
+				check.Chain = ChainOK
+				anchorRound, anchorHash = rec.Round, rec.Hash
+			}
+		}
+
+		dist := defaultDist
+		if rec.Distribution != "" {
+			parsed, err := ParseDistribution(rec.Distribution)
+			if err != nil {
+				check.Distribution = rec.Distribution
+				check.Error = err.Error()
+				checks = append(checks, check)
+				continue
+			}
+			dist = parsed
+		}
+		check.Distribution = dist.Spec()
+
+		if check.Hash != "" {
+			check.CrashPoint = dist.CrashPoint(RoundDigest(check.Hash, check.ClientSeed))
+		}
+
+		// A round passes only once its hash is linked to the chain
+		if check.Chain == ChainUnchecked && check.Error == "" {
+			check.Error = "no chain or terminating hash to check the hash against"
+		}
+		check.Pass = check.Error == "" && check.Chain == ChainOK
+		if rec.CrashPoint != 0 && check.CrashPoint != rec.CrashPoint {
+			check.Pass = false
+			if check.Error == "" {
+				check.Error = "crash point differs from the recorded one"
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
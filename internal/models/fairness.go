@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type ServerSeed struct {
+	ID              int        `json:"id"`
+	RoomID          string     `json:"roomId"`
+	Seed            string     `json:"seed,omitempty"` // only set once revealed
+	SeedHash        string     `json:"seedHash"`
+	TerminatingHash string     `json:"terminatingHash"`
+	ChainLength     int64      `json:"chainLength"`
+	Status          string     `json:"status"` // "pending", "active", "revealed"
+	CreatedAt       time.Time  `json:"createdAt"`
+	RevealedAt      *time.Time `json:"revealedAt,omitempty"`
+}
+
+type SeedPair struct {
+	ID           int       `json:"id"`
+	UserID       string    `json:"userId"`
+	ServerSeedID int       `json:"serverSeedId"`
+	ClientSeed   string    `json:"clientSeed"`
+	Nonce        int64     `json:"nonce"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
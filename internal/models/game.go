@@ -1,25 +1,28 @@
 package models
 
-import (
-	"crypto/sha256"
-	"encoding/binary"
-	"math"
-	"time"
-)
+import "time"
 
 type GameHistory struct {
-	GameID      string          `json:"game_id"`
-	CrashPoint  float64         `json:"crash_point"`
-	Hash        string          `json:"hash"`
-	StartTime   time.Time       `json:"start_time"`
-	EndTime     time.Time       `json:"end_time"`
-	BetAmount   float64         `json:"bet_amount"`
-	WinAmount   float64         `json:"win_amount"`
-	CashedOut   bool            `json:"cashed_out"`
-	CashoutAt   float64         `json:"cashout_at"`
-	AutoCashout float64         `json:"auto_cashout"`
-	Players     []PlayerHistory `json:"players,omitempty"`
-	Status      string          `json:"status"`
+	GameID       string          `json:"game_id"`
+	RoomID       string          `json:"room_id"`
+	Round        int64           `json:"round"`
+	ServerSeedID int             `json:"server_seed_id"`
+	ClientSeed   string          `json:"client_seed"`
+	Distribution string          `json:"distribution"`
+	Curve        string          `json:"curve,omitempty"`
+	MaxWin       float64         `json:"max_win,omitempty"`
+	MaxPayout    float64         `json:"max_payout,omitempty"`
+	CrashPoint   float64         `json:"crash_point"`
+	Hash         string          `json:"hash"`
+	StartTime    time.Time       `json:"start_time"`
+	EndTime      time.Time       `json:"end_time"`
+	BetAmount    float64         `json:"bet_amount"`
+	WinAmount    float64         `json:"win_amount"`
+	CashedOut    bool            `json:"cashed_out"`
+	CashoutAt    float64         `json:"cashout_at"`
+	AutoCashout  float64         `json:"auto_cashout"`
+	Players      []PlayerHistory `json:"players,omitempty"`
+	Status       string          `json:"status"`
 }
 
 type GameVerification struct {
@@ -37,23 +40,72 @@ type Player struct {
 }
 
 type PlayerHistory struct {
-	UserID      string     `json:"user_id"`
-	BetAmount   float64    `json:"bet_amount"`
-	WinAmount   float64    `json:"win_amount"`
-	CashedOut   bool       `json:"cashed_out"`
-	CashoutAt   *time.Time `json:"cashout_at"`
-	AutoCashout *float64   `json:"auto_cashout"`
+	BetID             int        `json:"bet_id,omitempty"`
+	UserID            string     `json:"user_id"`
+	Slot              int        `json:"slot"`
+	BetAmount         float64    `json:"bet_amount"`
+	WinAmount         float64    `json:"win_amount"`
+	CashedOut         bool       `json:"cashed_out"`
+	CashoutMultiplier float64    `json:"cashout_multiplier,omitempty"`
+	CashoutAt         *time.Time `json:"cashout_at"`
+	AutoCashout       *float64   `json:"auto_cashout"`
+	SeedPairID        int        `json:"seed_pair_id,omitempty"`
+	ClientSeed        string     `json:"client_seed,omitempty"`
+	Nonce             int64      `json:"nonce"`
+	// Cashouts are the cashouts of the bet in order; all but the last one of
+	// a cashed out bet, and every one of a bet still riding, are partial.
+	Cashouts []CashoutLine `json:"cashouts,omitempty"`
 }
 
-func CalculateCrashPoint(seed string) float64 {
-	// Convert hex seed to bytes
-	hash := sha256.Sum256([]byte(seed))
+// CashoutLine is a cashout of part or all of a bet's stake.
+type CashoutLine struct {
+	Stake      float64   `json:"stake"`
+	Multiplier float64   `json:"multiplier"`
+	WinAmount  float64   `json:"win_amount"`
+	CashoutAt  time.Time `json:"cashout_at"`
+}
 
-	// Use first 8 bytes as uint64
-	num := binary.BigEndian.Uint64(hash[:8])
+// Settlement is the final outcome of a crashed round, recorded when its
+// winners are credited.
+type Settlement struct {
+	GameID     string    `json:"game_id"`
+	CrashPoint float64   `json:"crash_point"`
+	CrashTime  time.Time `json:"crash_time"`
+	Bets       int       `json:"bets"`
+	Winners    int       `json:"winners"`
+	Wagered    float64   `json:"wagered"`
+	Paid       float64   `json:"paid"`
+	Attempts   int       `json:"attempts"`
+	SettledAt  time.Time `json:"settled_at"`
+}
 
-	// Generate float between 1 and 10
-	float := 1.0 + (float64(num)/float64(1<<64))*9.0
+// RoundVoid records a round voided by an admin: every stake was refunded and
+// every win already credited was taken back.
+type RoundVoid struct {
+	GameID   string      `json:"game_id"`
+	RoomID   string      `json:"room_id,omitempty"`
+	Reason   string      `json:"reason"`
+	Bets     int         `json:"bets"`
+	Refunded float64     `json:"refunded"`
+	Reversed float64     `json:"reversed"`
+	Refunds  []BetRefund `json:"refunds"`
+	VoidedBy int         `json:"voided_by"`
+	VoidedAt time.Time   `json:"voided_at"`
+}
+
+// BetRefund is what voiding a round did to one bet.
+type BetRefund struct {
+	BetID    int     `json:"bet_id"`
+	UserID   string  `json:"user_id"`
+	Refunded float64 `json:"refunded"`
+	Reversed float64 `json:"reversed"`
+}
 
-	return math.Floor(float*100) / 100 // Round to 2 decimal places
+// Room is a table rounds are played at, with its own bet limits.
+type Room struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	MinBet    float64   `json:"min_bet"`
+	MaxBet    float64   `json:"max_bet"`
+	CreatedAt time.Time `json:"created_at"`
 }
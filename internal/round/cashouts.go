@@ -0,0 +1,77 @@
+package round
+
+import "container/heap"
+
+// AutoCashout is a player's order to cash out once the round reaches Target.
+type AutoCashout struct {
+	UserID string
+	Target float64
+}
+
+// CashoutSchedule keeps the auto-cashouts of a round ordered by target, so a
+// tick only looks at the orders that are due instead of every player. Orders
+// with equal targets fire in the order they were added.
+type CashoutSchedule struct {
+	orders cashoutHeap
+	added  int
+}
+
+func NewCashoutSchedule() *CashoutSchedule {
+	return &CashoutSchedule{}
+}
+
+func (s *CashoutSchedule) Add(userID string, target float64) {
+	heap.Push(&s.orders, scheduledCashout{AutoCashout{userID, target}, s.added})
+	s.added++
+}
+
+func (s *CashoutSchedule) Len() int {
+	return s.orders.Len()
+}
+
+// Next returns the order with the lowest target without removing it.
+func (s *CashoutSchedule) Next() (AutoCashout, bool) {
+	if s.orders.Len() == 0 {
+		return AutoCashout{}, false
+	}
+	return s.orders[0].AutoCashout, true
+}
+
+// PopDue removes and returns, lowest target first, the orders due reports
+// as due. due must hold for every target up to some multiplier, so popping
+// stops at the first order that is not due.
+func (s *CashoutSchedule) PopDue(due func(AutoCashout) bool) []AutoCashout {
+	var orders []AutoCashout
+	for s.orders.Len() > 0 && due(s.orders[0].AutoCashout) {
+		orders = append(orders, heap.Pop(&s.orders).(scheduledCashout).AutoCashout)
+	}
+	return orders
+}
+
+type scheduledCashout struct {
+	AutoCashout
+	seq int
+}
+
+type cashoutHeap []scheduledCashout
+
+func (h cashoutHeap) Len() int { return len(h) }
+
+func (h cashoutHeap) Less(i, j int) bool {
+	if h[i].Target != h[j].Target {
+		return h[i].Target < h[j].Target
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h cashoutHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *cashoutHeap) Push(x interface{}) { *h = append(*h, x.(scheduledCashout)) }
+
+func (h *cashoutHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,128 @@
+package round
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"crash-game/internal/game"
+)
+
+var ErrInvalidConfig = errors.New("invalid round config")
+
+// Config sets the pacing of rounds. A round keeps the config it started with,
+// so changes only apply from the next round.
+type Config struct {
+	// BettingDuration is how long bets are accepted before a round starts.
+	BettingDuration time.Duration
+	// CooldownDuration is the pause between a crash and the next round.
+	CooldownDuration time.Duration
+	// TickInterval is how often a running round is checked for a crash and
+	// auto cashouts.
+	TickInterval time.Duration
+	Curve        game.GrowthCurve
+	// BetSlots is how many bets a player can place in one round, each with
+	// its own stake, auto-cashout and cashouts.
+	BetSlots int
+	// MaxWin caps the payout of a single bet; a bet is cashed out once it
+	// would pay more. Zero means no cap.
+	MaxWin float64
+	// MaxPayout caps what a round pays out in total; once the wins of the
+	// bets cashed out and the live value of the rest reach it, every open bet
+	// is cashed out. Zero means no cap.
+	MaxPayout float64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		BettingDuration:  5 * time.Second,
+		CooldownDuration: 2 * time.Second,
+		TickInterval:     100 * time.Millisecond,
+		Curve:            game.DefaultCurve(),
+		BetSlots:         2,
+	}
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.BettingDuration < time.Second || c.BettingDuration > 5*time.Minute:
+		return fmt.Errorf("%w: betting duration must be between 1s and 5m", ErrInvalidConfig)
+	case c.CooldownDuration < 0 || c.CooldownDuration > 5*time.Minute:
+		return fmt.Errorf("%w: cooldown duration must be between 0s and 5m", ErrInvalidConfig)
+	case c.TickInterval < 10*time.Millisecond || c.TickInterval > time.Second:
+		return fmt.Errorf("%w: tick interval must be between 10ms and 1s", ErrInvalidConfig)
+	case c.Curve == nil:
+		return fmt.Errorf("%w: growth curve is required", ErrInvalidConfig)
+	case c.BetSlots < 1 || c.BetSlots > 10:
+		return fmt.Errorf("%w: bet slots must be between 1 and 10", ErrInvalidConfig)
+	case c.MaxWin < 0 || c.MaxPayout < 0:
+		return fmt.Errorf("%w: max win and max payout must not be negative", ErrInvalidConfig)
+	}
+	return nil
+}
+
+type configJSON struct {
+	BettingDuration  string  `json:"bettingDuration"`
+	CooldownDuration string  `json:"cooldownDuration"`
+	TickInterval     string  `json:"tickInterval"`
+	Curve            string  `json:"curve"`
+	BetSlots         int     `json:"betSlots"`
+	MaxWin           float64 `json:"maxWin"`
+	MaxPayout        float64 `json:"maxPayout"`
+}
+
+func (c Config) MarshalJSON() ([]byte, error) {
+	raw := configJSON{
+		BettingDuration:  c.BettingDuration.String(),
+		CooldownDuration: c.CooldownDuration.String(),
+		TickInterval:     c.TickInterval.String(),
+		BetSlots:         c.BetSlots,
+		MaxWin:           c.MaxWin,
+		MaxPayout:        c.MaxPayout,
+	}
+	if c.Curve != nil {
+		raw.Curve = c.Curve.Spec()
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON reads durations like "5s" and a curve spec. Fields missing
+// from the input keep their current values, so a partial update can be
+// decoded over an existing config.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	current, err := c.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	var raw configJSON
+	if err := json.Unmarshal(current, &raw); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	next := Config{BetSlots: raw.BetSlots, MaxWin: raw.MaxWin, MaxPayout: raw.MaxPayout}
+	durations := []struct {
+		value string
+		dest  *time.Duration
+		name  string
+	}{
+		{raw.BettingDuration, &next.BettingDuration, "bettingDuration"},
+		{raw.CooldownDuration, &next.CooldownDuration, "cooldownDuration"},
+		{raw.TickInterval, &next.TickInterval, "tickInterval"},
+	}
+	for _, d := range durations {
+		if *d.dest, err = time.ParseDuration(d.value); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, d.name, err)
+		}
+	}
+
+	if next.Curve, err = game.ParseCurve(raw.Curve); err != nil {
+		return err
+	}
+
+	*c = next
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package round holds the lifecycle of a single crash round.
+//
+// A round moves through its states in a fixed order:
+//
+//	waiting -> betting -> closed -> in_progress -> crashed -> settled
+//
+// Every transition emits an event to the round's listeners. Any other
+// transition is rejected with a *TransitionError.
+package round
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"crash-game/internal/clock"
+)
+
+type State string
+
+const (
+	Waiting    State = "waiting"
+	Betting    State = "betting"
+	Closed     State = "closed" // betting closed, the crash point is being fixed
+	InProgress State = "in_progress"
+	Crashed    State = "crashed"
+	Settled    State = "settled"
+)
+
+// Finished reports whether the round has crashed, so its hash and crash
+// point may be revealed.
+func (s State) Finished() bool {
+	return s == Crashed || s == Settled
+}
+
+type EventType string
+
+const (
+	EventBettingOpen   EventType = "betting_open"
+	EventBettingClosed EventType = "betting_closed"
+	EventStarted       EventType = "started"
+	EventCrashed       EventType = "crashed"
+	EventSettled       EventType = "settled"
+)
+
+type Event struct {
+	Type    EventType `json:"type"`
+	RoundID string    `json:"roundId"`
+	From    State     `json:"from"`
+	To      State     `json:"to"`
+	At      time.Time `json:"at"`
+}
+
+// Listener is called synchronously on every transition, after the state has
+// changed. It must not block or call back into the machine.
+type Listener func(Event)
+
+var (
+	ErrInvalidTransition = errors.New("invalid round transition")
+	ErrWrongState        = errors.New("round is not in the required state")
+)
+
+// TransitionError is returned when a transition is not allowed from the
+// current state. It matches ErrInvalidTransition.
+type TransitionError struct {
+	RoundID string
+	From    State
+	To      State
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("round %s: cannot move from %s to %s", e.RoundID, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// StateError is returned by Require when the round is in another state. It
+// matches ErrWrongState.
+type StateError struct {
+	RoundID string
+	Want    State
+	Got     State
+}
+
+func (e *StateError) Error() string {
+	return fmt.Sprintf("round %s is %s, not %s", e.RoundID, e.Got, e.Want)
+}
+
+func (e *StateError) Unwrap() error {
+	return ErrWrongState
+}
+
+type transition struct {
+	from State
+	to   State
+}
+
+var transitions = map[transition]EventType{
+	{Waiting, Betting}:    EventBettingOpen,
+	{Betting, Closed}:     EventBettingClosed,
+	{Closed, InProgress}:  EventStarted,
+	{InProgress, Crashed}: EventCrashed,
+	{Crashed, Settled}:    EventSettled,
+}
+
+// Machine tracks the state of one round.
+type Machine struct {
+	mu        sync.RWMutex
+	id        string
+	state     State
+	clock     clock.Clock
+	listeners []Listener
+}
+
+// New returns the machine of a round in the waiting state.
+func New(id string, clk clock.Clock, listeners ...Listener) *Machine {
+	return &Machine{
+		id:        id,
+		state:     Waiting,
+		clock:     clk,
+		listeners: listeners,
+	}
+}
+
+func (m *Machine) ID() string {
+	return m.id
+}
+
+func (m *Machine) State() State {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Require returns a *StateError unless the round is in state s.
+func (m *Machine) Require(s State) error {
+	if got := m.State(); got != s {
+		return &StateError{RoundID: m.id, Want: s, Got: got}
+	}
+	return nil
+}
+
+func (m *Machine) OpenBetting() error  { return m.transition(Betting) }
+func (m *Machine) CloseBetting() error { return m.transition(Closed) }
+func (m *Machine) Start() error        { return m.transition(InProgress) }
+func (m *Machine) Crash() error        { return m.transition(Crashed) }
+func (m *Machine) Settle() error       { return m.transition(Settled) }
+
+func (m *Machine) transition(to State) error {
+	m.mu.Lock()
+	from := m.state
+	eventType, ok := transitions[transition{from, to}]
+	if !ok {
+		m.mu.Unlock()
+		return &TransitionError{RoundID: m.id, From: from, To: to}
+	}
+	m.state = to
+	m.mu.Unlock()
+
+	event := Event{
+		Type:    eventType,
+		RoundID: m.id,
+		From:    from,
+		To:      to,
+		At:      m.clock.Now(),
+	}
+	for _, listener := range m.listeners {
+		listener(event)
+	}
+	return nil
+}
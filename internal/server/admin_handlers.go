@@ -1,13 +1,49 @@
 package server
 
 import (
+	"crash-game/internal/auth"
 	"crash-game/internal/models"
 	"fmt"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
 )
 
+func (s *GameServer) AdminLogin(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid input"})
+		return
+	}
+
+	admin, passwordHash, err := s.db.GetAdminByUsername(req.Username)
+	if err != nil {
+		c.JSON(401, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil {
+		c.JSON(401, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	token, err := auth.GenerateAdminToken(admin.ID, admin.Role)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"token": token,
+		"admin": admin,
+	})
+}
+
 func (s *GameServer) adminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		adminID := c.GetInt("adminId")
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"crash-game/internal/audit"
+	"crash-game/internal/database"
+	"crash-game/internal/game"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFairnessAudit compares the recorded crash points of a distribution with
+// its theoretical one.
+func (s *GameServer) GetFairnessAudit(c *gin.Context) {
+	filter := database.AuditFilter{Distribution: c.DefaultQuery("distribution", s.distribution.Spec())}
+
+	var err error
+	if filter.From, err = parseAuditTime(c.Query("from")); err != nil {
+		c.JSON(400, gin.H{"error": "invalid from"})
+		return
+	}
+	if filter.To, err = parseAuditTime(c.Query("to")); err != nil {
+		c.JSON(400, gin.H{"error": "invalid to"})
+		return
+	}
+
+	var opts audit.Options
+	if v := c.Query("alpha"); v != "" {
+		alpha, err := strconv.ParseFloat(v, 64)
+		if err != nil || alpha <= 0 || alpha >= 1 {
+			c.JSON(400, gin.H{"error": "invalid alpha"})
+			return
+		}
+		opts.Alpha = alpha
+	}
+
+	report, err := audit.Run(s.db, filter, opts)
+	if errors.Is(err, game.ErrInvalidDistribution) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, audit.ErrNoRounds) {
+		c.JSON(404, gin.H{"error": "no rounds to audit"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ AUDIT: Failed to audit %s: %v", filter.Distribution, err)
+		c.JSON(500, gin.H{"error": "failed to run audit"})
+		return
+	}
+
+	c.JSON(200, report)
+}
+
+// parseAuditTime accepts a date or an RFC 3339 time. An empty value leaves
+// the range open.
+func parseAuditTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
@@ -0,0 +1,299 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"crash-game/internal/autobet"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errAutoBetRunning = errors.New("auto-bet already running")
+	errNoAutoBet      = errors.New("no auto-bet running")
+)
+
+// autoBet is a player's auto-bet session, betting in one slot of a room at
+// every betting phase.
+type autoBet struct {
+	userID string
+	roomID string
+	slot   int
+
+	mu      sync.Mutex
+	session *autobet.Session
+	gameID  string  // round of the bet in flight
+	bet     *Player // bet in flight
+	last    *autoBetResult
+	err     string // why the last bet could not be placed
+}
+
+type autoBetResult struct {
+	GameID     string  `json:"gameId"`
+	Amount     float64 `json:"amount"`
+	WinAmount  float64 `json:"winAmount"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// autoBetStatus describes an auto-bet session to its player.
+type autoBetStatus struct {
+	Event    string           `json:"event,omitempty"`
+	RoomID   string           `json:"roomId"`
+	Slot     int              `json:"slot"`
+	Config   autobet.Config   `json:"config"`
+	Progress autobet.Progress `json:"progress"`
+	LastBet  *autoBetResult   `json:"lastBet,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+func (a *autoBet) status(event string) autoBetStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return autoBetStatus{
+		Event:    event,
+		RoomID:   a.roomID,
+		Slot:     a.slot,
+		Config:   a.session.Config(),
+		Progress: a.session.Progress(),
+		LastBet:  a.last,
+		Error:    a.err,
+	}
+}
+
+// startAutoBet starts an auto-bet session of a player in a room. Its first
+// bet is placed when the next round opens betting. A player runs one
+// session at a time.
+func (s *GameServer) startAutoBet(userID string, r *Room, slot int, config autobet.Config) (*autoBet, error) {
+	session, err := autobet.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+	if slot < 0 || slot >= r.CurrentGame().Config.BetSlots {
+		return nil, errInvalidSlot
+	}
+
+	s.autoBetsMu.Lock()
+	if _, exists := s.autoBets[userID]; exists {
+		s.autoBetsMu.Unlock()
+		return nil, errAutoBetRunning
+	}
+	a := &autoBet{
+		userID:  userID,
+		roomID:  r.ID,
+		slot:    slot,
+		session: session,
+	}
+	if s.autoBets == nil {
+		s.autoBets = make(map[string]*autoBet)
+	}
+	s.autoBets[userID] = a
+	s.autoBetsMu.Unlock()
+
+	log.Printf("🤖 AUTOBET: %s started %s auto-bet in room %s, base amount %.2f",
+		userID, config.Strategy, r.ID, config.BaseAmount)
+	s.sendToUser(userID, WSMessage{Type: "autobet", Payload: a.status("started")})
+	return a, nil
+}
+
+// stopAutoBet stops a player's auto-bet session. A bet already placed plays
+// its round out.
+func (s *GameServer) stopAutoBet(userID string) (*autoBet, error) {
+	s.autoBetsMu.Lock()
+	a, exists := s.autoBets[userID]
+	s.autoBetsMu.Unlock()
+	if !exists {
+		return nil, errNoAutoBet
+	}
+
+	a.mu.Lock()
+	a.session.Stop(autobet.StopByPlayer)
+	a.mu.Unlock()
+	s.endAutoBet(a)
+	return a, nil
+}
+
+func (s *GameServer) playerAutoBet(userID string) *autoBet {
+	s.autoBetsMu.Lock()
+	defer s.autoBetsMu.Unlock()
+	return s.autoBets[userID]
+}
+
+// endAutoBet removes a stopped session and tells its player why it stopped.
+func (s *GameServer) endAutoBet(a *autoBet) {
+	s.autoBetsMu.Lock()
+	if s.autoBets[a.userID] == a {
+		delete(s.autoBets, a.userID)
+	}
+	s.autoBetsMu.Unlock()
+
+	status := a.status("stopped")
+	log.Printf("🤖 AUTOBET: %s stopped after %d rounds, profit %.2f: %s",
+		a.userID, status.Progress.Rounds, status.Progress.Profit, status.Progress.StopReason)
+	s.sendToUser(a.userID, WSMessage{Type: "autobet", Payload: status})
+}
+
+func (s *GameServer) roomAutoBets(roomID string) []*autoBet {
+	s.autoBetsMu.Lock()
+	defer s.autoBetsMu.Unlock()
+
+	var bets []*autoBet
+	for _, a := range s.autoBets {
+		if a.roomID == roomID {
+			bets = append(bets, a)
+		}
+	}
+	return bets
+}
+
+// placeAutoBets places the next bet of every auto-bet session of a room
+// whose round just opened betting, each on its own so a slow strategy script
+// holds up no other player. A session whose slot holds a bet its player
+// queued for the round sits the round out instead of racing that bet for
+// the slot.
+func (s *GameServer) placeAutoBets(r *Room, g *GameState, sessions []*autoBet, queued map[string]*Player) {
+	for _, a := range sessions {
+		if _, taken := queued[betKey(a.userID, a.slot)]; taken {
+			log.Printf("⏭️ AUTOBET: Slot %d of %s holds a queued bet, skipping round %s", a.slot, a.userID, g.GameID)
+			continue
+		}
+		go s.placeAutoBet(r, g, a)
+	}
+}
+
+// placeAutoBet places the next bet of a session like a bet placed by hand,
+// within the room's bet limits. A bet that cannot be placed stops the
+// session; a round that cannot take it under maintenance is skipped.
+func (s *GameServer) placeAutoBet(r *Room, g *GameState, a *autoBet) {
+	balance, err := s.db.GetUserBalance(a.userID)
+	if err != nil {
+		log.Printf("❌ AUTOBET: Failed to get balance of %s, skipping round %s: %v", a.userID, g.GameID, err)
+		return
+	}
+
+	// A strategy script runs here, off the room lock, and only sees the
+	// session's inputs
+	a.mu.Lock()
+	next, ok := a.session.NextBet(balance)
+	if err := a.session.Err(); err != nil {
+		a.err = err.Error()
+	}
+	a.mu.Unlock()
+	if !ok {
+		s.endAutoBet(a)
+		return
+	}
+
+	player := &Player{
+		UserID:      a.userID,
+		Slot:        a.slot,
+		BetAmount:   next.Amount,
+		AutoCashout: &next.AutoCashout,
+	}
+	err = r.checkBetLimits(next.Amount)
+	if err == nil {
+		err = s.placeBet(r, player)
+	}
+	if errors.Is(err, errMaintenance) {
+		return
+	}
+	if err != nil {
+		a.mu.Lock()
+		a.session.Stop(autobet.StopBetFailed)
+		a.err = err.Error()
+		a.mu.Unlock()
+		s.endAutoBet(a)
+		return
+	}
+
+	a.mu.Lock()
+	a.gameID = g.GameID
+	a.bet = player
+	a.mu.Unlock()
+	s.sendToUser(a.userID, WSMessage{Type: "autobet", Payload: a.status("bet_placed")})
+}
+
+// recordAutoBets records the outcome of the auto-bets of a round that just
+// crashed. A bet its player cancelled is not counted. The caller must hold
+// the room lock.
+func (s *GameServer) recordAutoBets(r *Room, g *GameState) {
+	for _, a := range s.roomAutoBets(r.ID) {
+		a.mu.Lock()
+		if a.gameID != g.GameID {
+			a.mu.Unlock()
+			continue
+		}
+		bet := a.bet
+		a.gameID, a.bet = "", nil
+		if g.Players[betKey(a.userID, a.slot)] != bet {
+			a.mu.Unlock()
+			continue
+		}
+
+		a.session.Record(bet.BetAmount, bet.WinAmount, g.CrashPoint)
+		a.last = &autoBetResult{
+			GameID:     g.GameID,
+			Amount:     bet.BetAmount,
+			WinAmount:  bet.WinAmount,
+			Multiplier: bet.Multiplier,
+		}
+		stopped := a.session.Stopped()
+		a.mu.Unlock()
+
+		if stopped {
+			s.endAutoBet(a)
+			continue
+		}
+		s.sendToUser(a.userID, WSMessage{Type: "autobet", Payload: a.status("round_ended")})
+	}
+}
+
+func (s *GameServer) StartAutoBet(c *gin.Context) {
+	room := s.requestRoom(c)
+	if room == nil {
+		return
+	}
+
+	var req struct {
+		autobet.Config
+		Slot int `json:"slot"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+
+	a, err := s.startAutoBet(c.GetString("userId"), room, req.Slot, req.Config)
+	switch {
+	case errors.Is(err, autobet.ErrInvalidConfig), errors.Is(err, errInvalidSlot):
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errAutoBetRunning):
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(500, gin.H{"error": "failed to start auto-bet"})
+		return
+	}
+
+	c.JSON(200, a.status(""))
+}
+
+func (s *GameServer) GetAutoBet(c *gin.Context) {
+	a := s.playerAutoBet(c.GetString("userId"))
+	if a == nil {
+		c.JSON(404, gin.H{"error": errNoAutoBet.Error()})
+		return
+	}
+	c.JSON(200, a.status(""))
+}
+
+func (s *GameServer) StopAutoBet(c *gin.Context) {
+	a, err := s.stopAutoBet(c.GetString("userId"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, a.status(""))
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"crash-game/internal/clock"
+	"crash-game/internal/game"
+	"crash-game/internal/models"
+	"crash-game/internal/round"
+	"crash-game/internal/wallet"
+)
+
+// Option configures a GameServer at construction time.
+type Option func(*GameServer)
+
+// WithDistribution selects the crash distribution new rounds are played with.
+func WithDistribution(dist game.CrashDistribution) Option {
+	return func(s *GameServer) {
+		s.distribution = dist
+	}
+}
+
+// WithClock replaces the system clock that drives round timing.
+func WithClock(c clock.Clock) Option {
+	return func(s *GameServer) {
+		s.clock = c
+	}
+}
+
+// WithRoundConfig fixes the round timings and growth curve of every room,
+// ignoring the configuration persisted by admins.
+func WithRoundConfig(cfg round.Config) Option {
+	return func(s *GameServer) {
+		s.roundConfig = cfg
+		s.roundConfigFixed = true
+	}
+}
+
+// WithRooms plays the given rooms instead of the rooms saved in the database.
+func WithRooms(rooms ...models.Room) Option {
+	return func(s *GameServer) {
+		s.fixedRooms = rooms
+	}
+}
+
+// WithWalletStore applies balance changes to store instead of the database,
+// for example to add latency in load tests.
+func WithWalletStore(store wallet.Store) Option {
+	return func(s *GameServer) {
+		s.walletStore = store
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"log"
+
+	"crash-game/internal/round"
+
+	"github.com/gin-gonic/gin"
+)
+
+// roundListener publishes the transitions of a round to the WebSocket clients
+// of its room. Transitions happen with the room's mu held, so the round can be
+// read directly.
+func (s *GameServer) roundListener(g *GameState) round.Listener {
+	return func(event round.Event) {
+		payload := gin.H{
+			"gameId": g.GameID,
+			"roomId": g.RoomID,
+			"round":  g.Round,
+			"status": event.To,
+			"at":     event.At,
+		}
+
+		switch event.Type {
+		case round.EventBettingOpen:
+			payload["serverSeedId"] = g.ServerSeedID
+			payload["startTime"] = g.StartTime
+			payload["config"] = g.Config
+		case round.EventBettingClosed:
+			payload["players"] = len(g.Players)
+		case round.EventStarted:
+			payload["clientSeed"] = g.ClientSeed
+			payload["startTime"] = g.StartTime
+		case round.EventCrashed:
+			payload["crashPoint"] = g.CrashPoint
+			payload["crashTime"] = g.CrashTime
+			payload["hash"] = g.Hash
+		}
+
+		log.Printf("🎮 ROUND: %s/%s %s -> %s", g.RoomID, g.GameID, event.From, event.To)
+		s.broadcastRoomMessage(g.RoomID, WSMessage{Type: string(event.Type), Payload: payload})
+	}
+}
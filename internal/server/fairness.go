@@ -0,0 +1,340 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+
+	"crash-game/internal/game"
+	"crash-game/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errRotationPending = errors.New("server seed rotation already scheduled")
+
+// newServerSeed generates a server seed of a room together with the chain it
+// commits to.
+func newServerSeed(roomID, status string) (*models.ServerSeed, *game.HashChain, error) {
+	seed, err := game.GenerateServerSeed()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chain := game.NewSeedChain(seed, game.DefaultChainLength)
+	return &models.ServerSeed{
+		RoomID:          roomID,
+		Seed:            seed,
+		SeedHash:        game.HashServerSeed(seed),
+		TerminatingHash: chain.TerminatingHash(),
+		ChainLength:     chain.Length(),
+		Status:          status,
+	}, chain, nil
+}
+
+// loadServerSeed restores a room's active server seed, its chain position and
+// any scheduled rotation. A room without a seed gets its first one here.
+func (s *GameServer) loadServerSeed(r *Room) error {
+	seed, err := s.db.GetActiveServerSeed(r.ID)
+	if err == sql.ErrNoRows {
+		var chain *game.HashChain
+		seed, chain, err = newServerSeed(r.ID, "active")
+		if err != nil {
+			return err
+		}
+		if err := s.db.CreateServerSeed(seed); err != nil {
+			return err
+		}
+		r.serverSeed, r.chain = seed, chain
+		log.Printf("🔐 SEED: Created server seed %d of room %s, commitment %s", seed.ID, r.ID, seed.SeedHash)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	r.serverSeed = seed
+	r.chain = game.NewSeedChain(seed.Seed, seed.ChainLength)
+
+	lastRound, err := s.db.GetLastGameRound(seed.ID)
+	if err != nil {
+		return err
+	}
+	if err := r.chain.SetNextRound(lastRound + 1); err != nil {
+		return err
+	}
+
+	if pending, err := s.db.GetPendingServerSeed(r.ID); err == nil {
+		r.pendingSeed = pending
+		r.pendingChain = game.NewSeedChain(pending.Seed, pending.ChainLength)
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+
+	log.Printf("🔐 SEED: Resumed server seed %d of room %s at round %d", seed.ID, r.ID, lastRound+1)
+	return nil
+}
+
+func (r *Room) activeSeed() (*models.ServerSeed, *game.HashChain) {
+	r.seedMu.RLock()
+	defer r.seedMu.RUnlock()
+	return r.serverSeed, r.chain
+}
+
+// nextRoundHash consumes the next chain hash for a new round of a room.
+// Scheduled rotations are applied here, at the room's round boundary; as no
+// other room plays the seed, the seed being revealed is never the one an
+// unfinished round is played with.
+func (s *GameServer) nextRoundHash(r *Room) (int, int64, string, error) {
+	r.seedMu.Lock()
+	defer r.seedMu.Unlock()
+
+	if r.pendingSeed != nil {
+		if err := s.applySeedRotation(r); err != nil {
+			log.Printf("❌ SEED: Failed to rotate server seed of room %s: %v", r.ID, err)
+		}
+	}
+
+	round, hash, err := r.chain.Next()
+	if err == game.ErrChainExhausted {
+		log.Printf("🔐 SEED: Chain of server seed %d exhausted, rotating", r.serverSeed.ID)
+		if err := s.scheduleSeedRotationLocked(r); err != nil {
+			return 0, 0, "", err
+		}
+		if err := s.applySeedRotation(r); err != nil {
+			return 0, 0, "", err
+		}
+		round, hash, err = r.chain.Next()
+	}
+	if err != nil {
+		return 0, 0, "", err
+	}
+
+	return r.serverSeed.ID, round, hash, nil
+}
+
+// applySeedRotation reveals a room's active seed and activates the pending
+// one. The caller must hold r.seedMu.
+func (s *GameServer) applySeedRotation(r *Room) error {
+	if err := s.db.ActivateServerSeed(r.pendingSeed.ID, r.serverSeed.ID); err != nil {
+		return err
+	}
+
+	revealed := *r.serverSeed
+	revealed.Status = "revealed"
+
+	r.pendingSeed.Status = "active"
+	r.serverSeed, r.chain = r.pendingSeed, r.pendingChain
+	r.pendingSeed, r.pendingChain = nil, nil
+
+	log.Printf("🔐 SEED: Revealed server seed %d of room %s, now playing seed %d", revealed.ID, r.ID, r.serverSeed.ID)
+
+	s.broadcastRoomMessage(r.ID, WSMessage{
+		Type: "seed_rotated",
+		Payload: gin.H{
+			"roomId":   r.ID,
+			"revealed": revealed,
+			"active":   publicServerSeed(*r.serverSeed),
+		},
+	})
+	return nil
+}
+
+// scheduleSeedRotation creates the seed that replaces a room's active one at
+// its next round boundary.
+func (s *GameServer) scheduleSeedRotation(r *Room) (*models.ServerSeed, error) {
+	r.seedMu.Lock()
+	defer r.seedMu.Unlock()
+
+	if r.pendingSeed != nil {
+		return nil, errRotationPending
+	}
+	if err := s.scheduleSeedRotationLocked(r); err != nil {
+		return nil, err
+	}
+	return r.pendingSeed, nil
+}
+
+func (s *GameServer) scheduleSeedRotationLocked(r *Room) error {
+	seed, chain, err := newServerSeed(r.ID, "pending")
+	if err != nil {
+		return err
+	}
+	if err := s.db.CreateServerSeed(seed); err != nil {
+		return err
+	}
+
+	r.pendingSeed, r.pendingChain = seed, chain
+	log.Printf("🔐 SEED: Scheduled server seed %d of room %s, commitment %s", seed.ID, r.ID, seed.SeedHash)
+	return nil
+}
+
+// terminatingHash returns the chain commitment of the given server seed.
+func (s *GameServer) terminatingHash(serverSeedID int) (string, error) {
+	for _, r := range s.Rooms() {
+		if seed, _ := r.activeSeed(); seed != nil && seed.ID == serverSeedID {
+			return seed.TerminatingHash, nil
+		}
+	}
+
+	seed, err := s.db.GetServerSeed(serverSeedID)
+	if err != nil {
+		return "", err
+	}
+	return seed.TerminatingHash, nil
+}
+
+// roundClientSeed combines the client seeds of every bettor of a round.
+func roundClientSeed(players map[string]*Player) string {
+	entries := make([]game.ClientSeedEntry, 0, len(players))
+	for _, player := range players {
+		if player.ClientSeed == "" {
+			continue
+		}
+		entries = append(entries, game.ClientSeedEntry{
+			UserID:     player.UserID,
+			ClientSeed: player.ClientSeed,
+			Nonce:      player.Nonce,
+		})
+	}
+	return game.CombineClientSeeds(entries)
+}
+
+// publicServerSeed hides the seed itself until it has been revealed.
+func publicServerSeed(seed models.ServerSeed) models.ServerSeed {
+	if seed.Status != "revealed" {
+		seed.Seed = ""
+	}
+	return seed
+}
+
+func (s *GameServer) GetHashChain(c *gin.Context) {
+	room := s.requestRoom(c)
+	if room == nil {
+		return
+	}
+	seed, chain := room.activeSeed()
+
+	c.JSON(200, gin.H{
+		"roomId":          room.ID,
+		"serverSeedId":    seed.ID,
+		"serverSeedHash":  seed.SeedHash,
+		"terminatingHash": chain.TerminatingHash(),
+		"length":          chain.Length(),
+		"nextRound":       chain.NextRound(),
+		"distribution":    s.distribution.Spec(),
+	})
+}
+
+func (s *GameServer) ListServerSeeds(c *gin.Context) {
+	seeds, err := s.db.ListServerSeeds()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to get server seeds"})
+		return
+	}
+
+	for i := range seeds {
+		seeds[i] = publicServerSeed(seeds[i])
+	}
+	c.JSON(200, gin.H{"seeds": seeds})
+}
+
+func (s *GameServer) GetSeedPair(c *gin.Context) {
+	room := s.requestRoom(c)
+	if room == nil {
+		return
+	}
+	userID := c.GetString("userId")
+	seed, chain := room.activeSeed()
+
+	pair, err := s.db.GetSeedPair(userID, seed.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to get seed pair"})
+		return
+	}
+
+	response := gin.H{
+		"roomId":          room.ID,
+		"serverSeedId":    seed.ID,
+		"serverSeedHash":  seed.SeedHash,
+		"terminatingHash": chain.TerminatingHash(),
+		"clientSeed":      pair.ClientSeed,
+		"nonce":           pair.Nonce,
+	}
+
+	room.seedMu.RLock()
+	if room.pendingSeed != nil {
+		response["nextServerSeedHash"] = room.pendingSeed.SeedHash
+	}
+	room.seedMu.RUnlock()
+
+	c.JSON(200, response)
+}
+
+func (s *GameServer) SetClientSeed(c *gin.Context) {
+	room := s.requestRoom(c)
+	if room == nil {
+		return
+	}
+
+	var req struct {
+		ClientSeed string `json:"clientSeed" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := game.ValidateClientSeed(req.ClientSeed); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("userId")
+	seed, _ := room.activeSeed()
+
+	pair, err := s.db.SetClientSeed(userID, seed.ID, req.ClientSeed)
+	if err != nil {
+		log.Printf("❌ SEED: Failed to set client seed for user %s: %v", userID, err)
+		c.JSON(500, gin.H{"error": "failed to set client seed"})
+		return
+	}
+
+	c.JSON(200, pair)
+}
+
+func (s *GameServer) RotateServerSeed(c *gin.Context) {
+	room := s.requestRoom(c)
+	if room == nil {
+		return
+	}
+	adminID := c.GetInt("adminId")
+	current, _ := room.activeSeed()
+
+	next, err := s.scheduleSeedRotation(room)
+	if err == errRotationPending {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ SEED: Failed to schedule rotation of room %s: %v", room.ID, err)
+		c.JSON(500, gin.H{"error": "failed to rotate server seed"})
+		return
+	}
+
+	if err := s.db.LogAdminAction(adminID, "server_seed_rotation", "server_seed", strconv.Itoa(current.ID), gin.H{
+		"roomId":       room.ID,
+		"nextSeedId":   next.ID,
+		"nextSeedHash": next.SeedHash,
+	}); err != nil {
+		log.Printf("❌ SEED: Failed to log admin action: %v", err)
+	}
+
+	c.JSON(200, gin.H{
+		"message":  "server seed will be revealed and replaced at the room's next round",
+		"current":  publicServerSeed(*current),
+		"nextSeed": publicServerSeed(*next),
+	})
+}
@@ -2,8 +2,14 @@ package server
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"time"
+
+	"crash-game/internal/database"
+	"crash-game/internal/game"
+	"crash-game/internal/models"
+	"crash-game/internal/round"
 )
 
 func (g *GameState) PlayerCashout(userID string, multiplier float64) error {
@@ -16,15 +22,389 @@ func (g *GameState) PlayerCashout(userID string, multiplier float64) error {
 	}
 
 	player.CashedOut = true
+	player.Multiplier = multiplier
 	player.WinAmount = player.BetAmount * multiplier
 	return nil
 }
 
-func (g *GameState) GetCurrentMultiplier() float64 {
-	if g.Status != "in_progress" {
+var (
+	errNotAcceptingBets = errors.New("game not accepting bets")
+	errBetPlaced        = errors.New("bet already placed for this game")
+	errInvalidSlot      = errors.New("invalid bet slot")
+	errAboveMaxWin      = errors.New("bet amount exceeds the maximum win per bet")
+	errBettingClosed    = errors.New("betting closed, bet can no longer be cancelled")
+	errBetQueued        = errors.New("bet already queued for the next round")
+	errBettingOpen      = errors.New("betting is open")
+	errNoActiveGame     = errors.New("no active game")
+	errNoBet            = errors.New("no bet found for this game")
+	errCashedOut        = errors.New("already cashed out")
+	errCashoutTooSmall  = errors.New("cashout amount too small")
+)
+
+// cashoutPortion is how much of a bet's open stake a cashout takes: a
+// fraction of it or an amount of it. Neither takes all of it.
+type cashoutPortion struct {
+	Fraction float64
+	Amount   float64
+}
+
+// stake returns the stake the portion cashes out of open, rounded to cents,
+// and whether that is all of it.
+func (c cashoutPortion) stake(open float64) (float64, bool) {
+	var stake float64
+	switch {
+	case c.Fraction > 0:
+		stake = math.Round(open*c.Fraction*100) / 100
+	case c.Amount > 0:
+		stake = math.Round(c.Amount*100) / 100
+	default:
+		return open, true
+	}
+	if stake >= open {
+		return open, true
+	}
+	return stake, false
+}
+
+// betKey is the key of a user's bet in a slot among the players of a round.
+// The first slot is keyed by the user alone.
+func betKey(userID string, slot int) string {
+	if slot == 0 {
+		return userID
+	}
+	return fmt.Sprintf("%s#%d", userID, slot)
+}
+
+// reserveBet holds a player's bet slot in the betting round while the stake
+// is debited, so a second bet in the same slot is rejected meanwhile. Every
+// reservation must be released with confirmBet.
+func (g *GameState) reserveBet(player *Player) error {
+	if g == nil || g.Require(round.Betting) != nil {
+		return errNotAcceptingBets
+	}
+	if player.Slot < 0 || player.Slot >= g.Config.BetSlots {
+		return errInvalidSlot
+	}
+	if g.Config.MaxWin > 0 && player.BetAmount > g.Config.MaxWin {
+		return errAboveMaxWin
+	}
+	key := betKey(player.UserID, player.Slot)
+	if _, exists := g.Players[key]; exists || g.reserved[key] {
+		return errBetPlaced
+	}
+
+	if g.reserved == nil {
+		g.reserved = make(map[string]bool)
+	}
+	g.reserved[key] = true
+	g.placements.Add(1)
+	return nil
+}
+
+// confirmBet releases a reservation, adding the player's bet to the round if
+// their stake was debited.
+func (g *GameState) confirmBet(player *Player, placed bool) {
+	key := betKey(player.UserID, player.Slot)
+	delete(g.reserved, key)
+	if placed {
+		g.Players[key] = player
+	}
+	g.placements.Done()
+}
+
+// cancelBet takes the bet under key out of the betting round while its stake
+// is refunded. The slot stays reserved and the round does not start until
+// the cancellation is released with confirmCancel, so a refund that fails
+// can still put the bet back.
+func (g *GameState) cancelBet(key string) (*Player, error) {
+	if g == nil || g.Require(round.Betting) != nil {
+		return nil, errBettingClosed
+	}
+	player, exists := g.Players[key]
+	if !exists {
+		return nil, errNoBet
+	}
+
+	delete(g.Players, key)
+	if g.reserved == nil {
+		g.reserved = make(map[string]bool)
+	}
+	g.reserved[key] = true
+	g.placements.Add(1)
+	return player, nil
+}
+
+// confirmCancel releases a cancellation, putting the bet back into the round
+// if its stake was not refunded.
+func (g *GameState) confirmCancel(player *Player, cancelled bool) {
+	key := betKey(player.UserID, player.Slot)
+	delete(g.reserved, key)
+	if !cancelled {
+		g.Players[key] = player
+	}
+	g.placements.Done()
+}
+
+// cashoutPlayer cashes portion of the bet under key out at the multiplier the
+// timeline reached at now and describes the cashout for the database. The
+// rest of a partially cashed out bet keeps riding.
+func (g *GameState) cashoutPlayer(key string, portion cashoutPortion, now time.Time) (database.Cashout, error) {
+	if g == nil || g.Require(round.InProgress) != nil {
+		return database.Cashout{}, errNoActiveGame
+	}
+
+	player, exists := g.Players[key]
+	if !exists {
+		return database.Cashout{}, errNoBet
+	}
+	if player.CashedOut {
+		return database.Cashout{}, errCashedOut
+	}
+
+	// Settle against the round timeline; the loop may not have ended a
+	// round whose crash instant already passed
+	multiplier, err := g.CashoutMultiplier(now)
+	if err != nil {
+		return database.Cashout{}, err
+	}
+
+	// Nor may it have applied a payout limit the round already reached
+	g.SettleDueCashouts(now)
+	if player.CashedOut {
+		return database.Cashout{}, errCashedOut
+	}
+
+	stake, all := portion.stake(player.openStake())
+	switch {
+	case all:
+		g.settle(player, multiplier, now)
+	case stake <= 0:
+		return database.Cashout{}, errCashoutTooSmall
+	default:
+		g.settlePart(player, stake, multiplier, now)
+		// What is left of the bet may win more before the max win
+		g.scheduleWinCap(key, player)
+	}
+	return g.cashout(player), nil
+}
+
+// ErrCashoutAfterCrash rejects a cashout that arrives at or after the crash
+// instant of a round, even if the game loop has not ended the round yet.
+var ErrCashoutAfterCrash = errors.New("round already crashed")
+
+// StartTimeline fixes the timeline of a round as it starts: the multiplier
+// grows along the round's curve from at, and the round crashes at the instant
+// the curve reaches the crash point. Every cashout is settled against it.
+// Auto-cashouts are scheduled by target, as bets are final by now, and so
+// is the cashout of every bet that would exceed the round's max win first.
+func (g *GameState) StartTimeline(at time.Time) {
+	g.StartTime = at
+	g.CrashTime = g.MultiplierTime(g.CrashPoint)
+
+	g.autoCashouts = round.NewCashoutSchedule()
+	g.openStake, g.paid = 0, 0
+	for key, player := range g.Players {
+		if player.CashedOut {
+			continue
+		}
+		g.openStake += player.BetAmount
+
+		if player.AutoCashout != nil {
+			g.autoCashouts.Add(key, *player.AutoCashout)
+		}
+		g.scheduleWinCap(key, player)
+	}
+}
+
+// MultiplierTime returns the instant the round reaches multiplier.
+func (g *GameState) MultiplierTime(multiplier float64) time.Time {
+	return g.StartTime.Add(g.Config.Curve.TimeFor(multiplier))
+}
+
+// MultiplierAt returns the multiplier of a round in progress at the given
+// time. It never exceeds the crash point.
+func (g *GameState) MultiplierAt(now time.Time) float64 {
+	if g.State() != round.InProgress {
+		return 0
+	}
+	if !now.Before(g.CrashTime) {
+		return g.CrashPoint
+	}
+	return multiplierAt(g.Config.Curve, now.Sub(g.StartTime))
+}
+
+// CashoutMultiplier returns the multiplier a cashout arriving at the given
+// time is settled at. Cashouts at or after the crash instant are rejected.
+func (g *GameState) CashoutMultiplier(at time.Time) (float64, error) {
+	if err := g.Require(round.InProgress); err != nil {
+		return 0, err
+	}
+	if !at.Before(g.CrashTime) {
+		return 0, ErrCashoutAfterCrash
+	}
+	return multiplierAt(g.Config.Curve, at.Sub(g.StartTime)), nil
+}
+
+// settle cashes the open stake of a player out of the round and keeps the
+// round's payout up to date.
+func (g *GameState) settle(p *Player, multiplier float64, at time.Time) {
+	g.settlePart(p, p.openStake(), multiplier, at)
+	p.CashedOut = true
+	p.Multiplier = multiplier
+}
+
+// settlePart cashes stake of a player's bet out and keeps the round's
+// payout up to date.
+func (g *GameState) settlePart(p *Player, stake, multiplier float64, at time.Time) {
+	p.Cashouts = append(p.Cashouts, models.CashoutLine{
+		Stake:      stake,
+		Multiplier: multiplier,
+		WinAmount:  stake * multiplier,
+		CashoutAt:  at,
+	})
+	p.WinAmount += stake * multiplier
+	p.CashoutAt = &at
+	g.openStake -= stake
+	g.paid += stake * multiplier
+}
+
+// openStake returns the part of the stake not cashed out yet.
+func (p *Player) openStake() float64 {
+	if p.CashedOut {
 		return 0
 	}
-	elapsed := time.Since(g.StartTime).Seconds()
-	multiplier := math.Pow(math.E, 0.1*elapsed)
-	return math.Floor(multiplier*100) / 100
+	open := p.BetAmount
+	for _, line := range p.Cashouts {
+		open -= line.Stake
+	}
+	return open
+}
+
+// scheduledAt reports whether the player still has a cashout order at
+// target: their auto-cashout or the max win of what is left of the bet.
+func (p *Player) scheduledAt(target float64) bool {
+	return target == p.winCap || (p.AutoCashout != nil && target == *p.AutoCashout)
+}
+
+// multiplierAt returns the multiplier a round reaches after running for
+// elapsed, floored to cents.
+func multiplierAt(curve game.GrowthCurve, elapsed time.Duration) float64 {
+	return math.Floor(curve.Multiplier(elapsed)*100) / 100
+}
+
+// SettleDueCashouts cashes out the auto-cashouts the timeline reached by
+// now, each at its target and the instant the target was reached, and the
+// bets the payout limits of the round stop. Targets at or above the crash
+// point are never reached. Forced cashouts are kept for TakeForcedCashouts.
+func (g *GameState) SettleDueCashouts(now time.Time) {
+	if g.autoCashouts == nil {
+		return
+	}
+
+	// Every auto-cashout below the round's payout cap lowers what the open
+	// bets will pay and so raises the cap; settle until none is left due
+	for {
+		capAt := g.payoutCap()
+		due := g.autoCashouts.PopDue(func(order round.AutoCashout) bool {
+			return order.Target < g.CrashPoint && (capAt == 0 || order.Target <= capAt) &&
+				!now.Before(g.MultiplierTime(order.Target))
+		})
+		if len(due) == 0 {
+			break
+		}
+
+		for _, order := range due {
+			player, exists := g.Players[order.UserID]
+			if !exists || player.CashedOut || !player.scheduledAt(order.Target) {
+				continue
+			}
+			g.settle(player, order.Target, g.MultiplierTime(order.Target))
+			if player.AutoCashout == nil || *player.AutoCashout != order.Target {
+				g.forced = append(g.forced, ForcedCashout{
+					Reason:  ForcedMaxWin,
+					Cashout: g.cashout(player),
+				})
+			}
+		}
+	}
+
+	g.settlePayoutCap(now)
+}
+
+// cashout describes a player's latest cashout for the database.
+func (g *GameState) cashout(player *Player) database.Cashout {
+	line := player.Cashouts[len(player.Cashouts)-1]
+	return database.Cashout{
+		BetID:      player.BetID,
+		GameID:     g.GameID,
+		UserID:     player.UserID,
+		Seq:        len(player.Cashouts),
+		Stake:      line.Stake,
+		Multiplier: line.Multiplier,
+		WinAmount:  line.WinAmount,
+		At:         line.CashoutAt,
+		Final:      player.CashedOut,
+	}
+}
+
+// record describes the round and its bets for the database.
+func (g *GameState) record(status string) *models.GameHistory {
+	history := &models.GameHistory{
+		GameID:       g.GameID,
+		RoomID:       g.RoomID,
+		Round:        g.Round,
+		ServerSeedID: g.ServerSeedID,
+		ClientSeed:   g.ClientSeed,
+		Distribution: g.Distribution.Spec(),
+		Curve:        g.Config.Curve.Spec(),
+		MaxWin:       g.Config.MaxWin,
+		MaxPayout:    g.Config.MaxPayout,
+		CrashPoint:   g.CrashPoint,
+		StartTime:    g.StartTime,
+		EndTime:      g.EndTime,
+		Hash:         g.Hash,
+		Status:       status,
+		Players:      make([]models.PlayerHistory, 0, len(g.Players)),
+	}
+
+	for _, player := range g.Players {
+		history.Players = append(history.Players, player.record())
+	}
+	return history
+}
+
+func (p *Player) record() models.PlayerHistory {
+	return models.PlayerHistory{
+		BetID:             p.BetID,
+		UserID:            p.UserID,
+		Slot:              p.Slot,
+		BetAmount:         p.BetAmount,
+		WinAmount:         p.WinAmount,
+		CashedOut:         p.CashedOut,
+		CashoutMultiplier: p.Multiplier,
+		CashoutAt:         p.CashoutAt,
+		AutoCashout:       p.AutoCashout,
+		SeedPairID:        p.SeedPairID,
+		ClientSeed:        p.ClientSeed,
+		Nonce:             p.Nonce,
+		Cashouts:          append([]models.CashoutLine(nil), p.Cashouts...),
+	}
+}
+
+// nextWakeup returns when the game loop has to act next: the next
+// auto-cashout target, the round's payout cap or the crash, whichever comes
+// first.
+func (g *GameState) nextWakeup() time.Time {
+	wakeup := g.CrashTime
+	if g.autoCashouts != nil {
+		if next, ok := g.autoCashouts.Next(); ok && next.Target < g.CrashPoint {
+			wakeup = g.MultiplierTime(next.Target)
+		}
+	}
+	if capAt := g.payoutCap(); capAt > 0 && capAt < g.CrashPoint {
+		if at := g.MultiplierTime(capAt); at.Before(wakeup) {
+			wakeup = at
+		}
+	}
+	return wakeup
 }
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ModeRunning     = "running"
+	ModePaused      = "paused"      // no new rounds start
+	ModeMaintenance = "maintenance" // no new rounds start and bets are rejected
+)
+
+// gameModeKey is the game_settings key the game mode is saved under, so
+// maintenance outlasts a restart.
+const gameModeKey = "game_mode"
+
+// pausePollInterval is how often a paused game loop checks whether it may
+// start the next round.
+const pausePollInterval = 500 * time.Millisecond
+
+var (
+	errMaintenance   = errors.New("game under maintenance")
+	errAlreadyPaused = errors.New("game already paused")
+	errNotPaused     = errors.New("game is not paused")
+)
+
+// GameMode is whether the rooms start new rounds and take bets. A round that
+// is already running always finishes and settles.
+type GameMode struct {
+	Mode    string    `json:"mode"`
+	Message string    `json:"message,omitempty"`
+	Since   time.Time `json:"since"`
+}
+
+func (s *GameServer) Mode() GameMode {
+	s.modeMu.Lock()
+	defer s.modeMu.Unlock()
+	return s.mode
+}
+
+// Pause stops every room from starting new rounds. A room whose round is
+// running plays it to its crash and settles it; the mode changes at once and
+// does not wait for that.
+func (s *GameServer) Pause() (GameMode, error) {
+	return s.changeMode(pauseMode, s.saveMode)
+}
+
+// EnterMaintenance pauses the game and rejects new bets, with a notice for
+// the players. It also updates the notice of a game already in maintenance.
+func (s *GameServer) EnterMaintenance(message string) (GameMode, error) {
+	return s.changeMode(maintenanceMode(message), s.saveMode)
+}
+
+// Resume lets the rooms start new rounds and take bets again.
+func (s *GameServer) Resume() (GameMode, error) {
+	return s.changeMode(resumeMode, s.saveMode)
+}
+
+func pauseMode(current GameMode) (GameMode, error) {
+	if current.Mode != ModeRunning {
+		return current, errAlreadyPaused
+	}
+	return GameMode{Mode: ModePaused}, nil
+}
+
+func maintenanceMode(message string) func(GameMode) (GameMode, error) {
+	return func(GameMode) (GameMode, error) {
+		return GameMode{Mode: ModeMaintenance, Message: message}, nil
+	}
+}
+
+func resumeMode(current GameMode) (GameMode, error) {
+	if current.Mode == ModeRunning {
+		return current, errNotPaused
+	}
+	return GameMode{Mode: ModeRunning}, nil
+}
+
+func (s *GameServer) saveMode(mode GameMode) error {
+	return s.db.SaveGameSetting(gameModeKey, mode)
+}
+
+// changeMode saves and applies the mode next returns for the current one and
+// announces it to every WebSocket client. The mode is only applied once save
+// succeeds.
+func (s *GameServer) changeMode(next func(GameMode) (GameMode, error), save func(GameMode) error) (GameMode, error) {
+	s.modeMu.Lock()
+	mode, err := next(s.mode)
+	if err != nil {
+		s.modeMu.Unlock()
+		return mode, err
+	}
+	mode.Since = s.clock.Now()
+	if err := save(mode); err != nil {
+		s.modeMu.Unlock()
+		return mode, err
+	}
+	s.mode = mode
+	s.modeMu.Unlock()
+
+	log.Printf("⚙️ CONFIG: Game is now %s", mode.Mode)
+	s.broadcastMessage(WSMessage{Type: "game_mode", Payload: mode})
+	return mode, nil
+}
+
+// loadGameMode restores the game mode saved by ops, if any.
+func (s *GameServer) loadGameMode() {
+	s.mode = GameMode{Mode: ModeRunning, Since: s.clock.Now()}
+
+	var mode GameMode
+	err := s.db.GetGameSetting(gameModeKey, &mode)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		log.Printf("❌ CONFIG: Failed to load the game mode, running: %v", err)
+		return
+	}
+
+	s.mode = mode
+	if mode.Mode != ModeRunning {
+		log.Printf("⚙️ CONFIG: Game is %s since %s", mode.Mode, mode.Since.Format(time.RFC3339))
+	}
+}
+
+// checkAcceptingBets rejects bets while the game is in maintenance.
+func (s *GameServer) checkAcceptingBets() error {
+	if s.Mode().Mode == ModeMaintenance {
+		return errMaintenance
+	}
+	return nil
+}
+
+// waitWhilePaused holds a room's game loop between rounds while the game is
+// paused or in maintenance.
+func (s *GameServer) waitWhilePaused(r *Room) {
+	if s.Mode().Mode == ModeRunning {
+		return
+	}
+
+	log.Printf("⏸️ ROOM: %s paused", r.ID)
+	for s.Mode().Mode != ModeRunning {
+		s.clock.Sleep(pausePollInterval)
+	}
+	log.Printf("▶️ ROOM: %s resumed", r.ID)
+}
+
+func (s *GameServer) GetGameMode(c *gin.Context) {
+	c.JSON(200, s.Mode())
+}
+
+func (s *GameServer) PauseGame(c *gin.Context) {
+	s.updateGameMode(c, "game_pause", pauseMode)
+}
+
+func (s *GameServer) ResumeGame(c *gin.Context) {
+	s.updateGameMode(c, "game_resume", resumeMode)
+}
+
+func (s *GameServer) StartMaintenance(c *gin.Context) {
+	var req struct {
+		Message string `json:"message" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "message is required"})
+		return
+	}
+
+	s.updateGameMode(c, "game_maintenance", maintenanceMode(req.Message))
+}
+
+// updateGameMode applies a mode change requested by an admin. The mode is
+// saved together with its admin action, and left unchanged if either fails.
+func (s *GameServer) updateGameMode(c *gin.Context, action string, next func(GameMode) (GameMode, error)) {
+	adminID := c.GetInt("adminId")
+
+	mode, err := s.changeMode(next, func(mode GameMode) error {
+		return s.db.SaveGameSettingByAdmin(adminID, action, gameModeKey, mode)
+	})
+	if errors.Is(err, errAlreadyPaused) || errors.Is(err, errNotPaused) {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ CONFIG: Failed to save the game mode: %v", err)
+		c.JSON(500, gin.H{"error": "failed to change game mode"})
+		return
+	}
+
+	c.JSON(200, mode)
+}
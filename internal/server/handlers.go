@@ -1,11 +1,16 @@
 package server
 
 import (
+	"crash-game/internal/database"
 	"crash-game/internal/game"
 	"crash-game/internal/models"
 
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,9 +19,7 @@ import (
 )
 
 func (s *GameServer) GetGameHistory(c *gin.Context) {
-	userID := c.GetString("userId")
-
-	history, err := s.db.GetGameHistory(userID)
+	history, err := s.db.GetGameHistory(c.Query("roomId"))
 	if err != nil {
 		c.JSON(500, gin.H{"error": "failed to get game history"})
 		return
@@ -59,6 +62,9 @@ func (s *GameServer) PlaceBet(c *gin.Context) {
 	var req struct {
 		Amount      float64  `json:"amount" binding:"required,gt=0"`
 		AutoCashout *float64 `json:"auto_cashout" binding:"omitempty,gt=1"`
+		Slot        int      `json:"slot"`
+		// Queue holds the bet for the next round if betting is closed
+		Queue bool `json:"queue"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -70,8 +76,18 @@ func (s *GameServer) PlaceBet(c *gin.Context) {
 		return
 	}
 
+	room := s.requestRoom(c)
+	if room == nil {
+		return
+	}
+
 	userID := c.GetString("userId")
-	log.Printf("Placing bet for user %s, amount: %f", userID, req.Amount)
+	log.Printf("Placing bet for user %s in room %s, amount: %f", userID, room.ID, req.Amount)
+
+	if err := s.checkAcceptingBets(); err != nil {
+		c.JSON(503, gin.H{"error": err.Error(), "message": s.Mode().Message})
+		return
+	}
 
 	// Check balance first
 	balance, err := s.db.GetUserBalance(userID)
@@ -86,32 +102,9 @@ func (s *GameServer) PlaceBet(c *gin.Context) {
 		return
 	}
 
-	// Then check maximum bet
-	const maxBetAmount = 1000.0
-	if req.Amount > maxBetAmount {
-		c.JSON(400, gin.H{"error": "bet amount exceeds maximum allowed"})
-		return
-	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Validate game state BEFORE deducting balance
-	if s.currentGame == nil || s.currentGame.Status != "betting" {
-		c.JSON(400, gin.H{"error": "game not accepting bets"})
-		return
-	}
-
-	// Check for existing bet
-	if _, exists := s.currentGame.Players[userID]; exists {
-		c.JSON(400, gin.H{"error": "bet already placed for this game"})
-		return
-	}
-
-	// Deduct bet amount ONLY after all validations pass
-	if err := s.db.UpdateBalance(userID, req.Amount, "debit"); err != nil {
-		log.Printf("❌ BET: Failed to update balance: %v", err)
-		c.JSON(500, gin.H{"error": "failed to update balance"})
+	// Then check the bet limits of the room
+	if err := room.checkBetLimits(req.Amount); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -126,34 +119,113 @@ func (s *GameServer) PlaceBet(c *gin.Context) {
 	log.Printf("🎯 DEBUG: [BET] Setting up bet - User: %s, Amount: %.2f, AutoCashout: %s",
 		userID, req.Amount, autoCashoutValue)
 
-	s.currentGame.Players[userID] = &Player{
+	player := &Player{
 		UserID:      userID,
+		Slot:        req.Slot,
 		BetAmount:   req.Amount,
 		CashedOut:   false,
 		WinAmount:   0,
 		AutoCashout: req.AutoCashout,
 	}
 
-	// Verify the bet was recorded correctly
-	if player := s.currentGame.Players[userID]; player != nil {
-		actualAutoCashout := "<nil>"
-		if player.AutoCashout != nil {
-			actualAutoCashout = fmt.Sprintf("%.2f", *player.AutoCashout)
+	// The round is checked BEFORE the stake is debited; the debit and the
+	// bet are persisted together
+	err = s.placeBet(room, player)
+	if errors.Is(err, errNotAcceptingBets) && req.Queue {
+		err = s.queueBet(room, player)
+		if err == nil {
+			c.JSON(202, gin.H{
+				"success": true,
+				"queued":  true,
+				"slot":    player.Slot,
+				"amount":  req.Amount,
+			})
+			return
+		}
+		// Betting opened meanwhile
+		if errors.Is(err, errBettingOpen) {
+			err = s.placeBet(room, player)
 		}
-		log.Printf("✅ DEBUG: [BET] Bet recorded - User: %s, AutoCashout: %s",
-			userID, actualAutoCashout)
+	}
+	switch {
+	case errors.Is(err, errMaintenance):
+		c.JSON(503, gin.H{"error": err.Error(), "message": s.Mode().Message})
+		return
+	case errors.Is(err, errNotAcceptingBets), errors.Is(err, errBetPlaced), errors.Is(err, errInvalidSlot),
+		errors.Is(err, errAboveMaxWin), errors.Is(err, errBetQueued):
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, database.ErrInsufficientBalance):
+		c.JSON(400, gin.H{"error": "insufficient balance"})
+		return
+	case err != nil:
+		log.Printf("❌ BET: Failed to place bet: %v", err)
+		c.JSON(500, gin.H{"error": "failed to place bet"})
+		return
 	}
 
-	if req.AutoCashout != nil {
-		log.Printf("🎯 DEBUG: Auto-cashout set to %.2fx for user %s", *req.AutoCashout, userID)
+	autoCashoutValue = "<nil>"
+	if player.AutoCashout != nil {
+		autoCashoutValue = fmt.Sprintf("%.2f", *player.AutoCashout)
 	}
+	log.Printf("✅ DEBUG: [BET] Bet recorded - User: %s, BetID: %d, AutoCashout: %s",
+		userID, player.BetID, autoCashoutValue)
 
 	c.JSON(200, gin.H{
 		"success": true,
+		"betId":   player.BetID,
+		"slot":    player.Slot,
 		"amount":  req.Amount,
 	})
 }
 
+func (s *GameServer) CancelBet(c *gin.Context) {
+	room := s.requestRoom(c)
+	if room == nil {
+		return
+	}
+
+	slot, err := strconv.Atoi(c.DefaultQuery("slot", "0"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid slot"})
+		return
+	}
+
+	userID := c.GetString("userId")
+	player, queued, err := s.cancelBet(room, userID, slot)
+	switch {
+	case errors.Is(err, errBettingClosed), errors.Is(err, database.ErrBetNotCancellable):
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errNoBet):
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("❌ BET: Failed to cancel bet: %v", err)
+		c.JSON(500, gin.H{"error": "failed to cancel bet"})
+		return
+	}
+
+	response := cancelledBet(room, player, queued)
+	response["success"] = true
+	c.JSON(200, response)
+}
+
+// cancelledBet describes a cancelled bet; a queued bet was never debited.
+func cancelledBet(r *Room, player *Player, queued bool) gin.H {
+	refunded := player.BetAmount
+	if queued {
+		refunded = 0
+	}
+	return gin.H{
+		"roomId":   r.ID,
+		"betId":    player.BetID,
+		"slot":     player.Slot,
+		"queued":   queued,
+		"refunded": refunded,
+	}
+}
+
 func (s *GameServer) RequestWithdrawal(c *gin.Context) {
 	userID := c.GetString("userId")
 	log.Printf("Starting withdrawal request for user: %s", userID)
@@ -270,69 +342,88 @@ func (s *GameServer) UpdateSettings(c *gin.Context) {
 func (s *GameServer) Cashout(c *gin.Context) {
 	userID := c.GetString("userId")
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.currentGame == nil || s.currentGame.Status != "in_progress" {
-		c.JSON(400, gin.H{"error": "no active game"})
+	room := s.requestRoom(c)
+	if room == nil {
 		return
 	}
 
-	player, exists := s.currentGame.Players[userID]
-	if !exists {
-		c.JSON(400, gin.H{"error": "no bet found for this game"})
+	// Without a body the whole bet in the first slot is cashed out
+	var req struct {
+		Slot     int      `json:"slot"`
+		Fraction *float64 `json:"fraction"`
+		Amount   *float64 `json:"amount"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(400, gin.H{"error": "invalid request"})
 		return
 	}
-
-	if player.CashedOut {
-		c.JSON(400, gin.H{"error": "already cashed out"})
+	var portion cashoutPortion
+	switch {
+	case req.Fraction != nil && req.Amount != nil:
+		c.JSON(400, gin.H{"error": "give either fraction or amount"})
 		return
+	case req.Fraction != nil:
+		if *req.Fraction <= 0 || *req.Fraction > 1 {
+			c.JSON(400, gin.H{"error": "fraction must be above 0 and at most 1"})
+			return
+		}
+		portion.Fraction = *req.Fraction
+	case req.Amount != nil:
+		if *req.Amount <= 0 {
+			c.JSON(400, gin.H{"error": "amount must be positive"})
+			return
+		}
+		portion.Amount = *req.Amount
 	}
 
-	multiplier := s.currentGame.GetCurrentMultiplier()
-	winAmount := player.BetAmount * multiplier
-
-	// Update player state
-	player.CashedOut = true
-	player.WinAmount = winAmount
-	now := time.Now()
-	player.CashoutAt = &now
-
-	// Add debug logging
-	initialBalance, _ := s.db.GetUserBalance(userID)
-	log.Printf("DEBUG: Balance before cashout: %f", initialBalance)
-
-	// Update user balance with winnings
-	if err := s.db.UpdateBalance(userID, winAmount, "credit"); err != nil {
-		c.JSON(500, gin.H{"error": "failed to update balance"})
+	// The winnings are credited when the round is settled
+	cashout, err := s.cashoutPlayer(room, userID, req.Slot, portion)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Verify credit
-	finalBalance, _ := s.db.GetUserBalance(userID)
-	log.Printf("DEBUG: Balance after cashout: %f (win amount: %f)", finalBalance, winAmount)
-
 	c.JSON(200, gin.H{
 		"success":    true,
-		"multiplier": multiplier,
-		"winAmount":  winAmount,
+		"slot":       req.Slot,
+		"multiplier": cashout.Multiplier,
+		"stake":      cashout.Stake,
+		"winAmount":  cashout.WinAmount,
+		"cashedOut":  cashout.Final,
 	})
 }
 
 func (s *GameServer) GetCurrentGame(c *gin.Context) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	room := s.requestRoom(c)
+	if room == nil {
+		return
+	}
 
-	if s.currentGame == nil {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	if room.currentGame == nil {
 		c.JSON(404, gin.H{"error": "no active game"})
 		return
 	}
 
+	// The round hash determines the crash point, so it is only revealed
+	// once the round has crashed
+	var hash string
+	if room.currentGame.State().Finished() {
+		hash = room.currentGame.Hash
+	}
+
 	c.JSON(200, gin.H{
-		"gameId":  s.currentGame.GameID,
-		"status":  s.currentGame.Status,
-		"hash":    s.currentGame.Hash,
-		"players": s.currentGame.Players,
+		"gameId":       room.currentGame.GameID,
+		"roomId":       room.ID,
+		"round":        room.currentGame.Round,
+		"status":       room.currentGame.State(),
+		"hash":         hash,
+		"clientSeed":   room.currentGame.ClientSeed,
+		"serverSeedId": room.currentGame.ServerSeedID,
+		"distribution": room.currentGame.Distribution.Spec(),
+		"players":      room.currentGame.Players,
 	})
 }
 
@@ -362,22 +453,30 @@ func (s *GameServer) VerifyGameFairness(c *gin.Context) {
 		return
 	}
 
-	// Check current game first
-	s.mu.RLock()
-	if s.currentGame != nil && s.currentGame.GameID == req.GameID {
-		if s.currentGame.Hash == req.Hash {
-			seed := s.currentGame.Hash[:8]
-			crashPoint := game.CalculateCrashPoint(seed)
-			s.mu.RUnlock()
-			c.JSON(200, gin.H{
-				"valid":      true,
-				"crashPoint": crashPoint,
-				"seed":       seed,
-			})
+	// Check the current games of the rooms first
+	if room := s.playingRoom(req.GameID); room != nil {
+		room.mu.RLock()
+		if !room.currentGame.State().Finished() {
+			room.mu.RUnlock()
+			c.JSON(400, gin.H{"error": "round still in progress"})
 			return
 		}
+		// A finished round no longer changes, so it can be read unlocked
+		current := room.currentGame
+		room.mu.RUnlock()
+
+		if current.Hash != req.Hash {
+			c.JSON(400, gin.H{"error": "invalid hash"})
+			return
+		}
+		terminatingHash, err := s.terminatingHash(current.ServerSeedID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "failed to get server seed"})
+			return
+		}
+		c.JSON(200, game.VerifyRound(current.Distribution, current.Round, current.Hash, current.ClientSeed, terminatingHash))
+		return
 	}
-	s.mu.RUnlock()
 
 	// If not current game, check database
 	gameData, err := s.db.GetGameByID(req.GameID)
@@ -392,13 +491,27 @@ func (s *GameServer) VerifyGameFairness(c *gin.Context) {
 		return
 	}
 
-	seed := gameData.Hash[:8]
-	crashPoint := game.CalculateCrashPoint(seed)
-	c.JSON(200, gin.H{
-		"valid":      true,
-		"crashPoint": crashPoint,
-		"seed":       seed,
-	})
+	terminatingHash, err := s.terminatingHash(gameData.ServerSeedID)
+	if err != nil {
+		log.Printf("❌ VERIFY: Server seed lookup failed: %v", err)
+		c.JSON(500, gin.H{"error": "failed to get server seed"})
+		return
+	}
+
+	dist, err := game.ParseDistribution(gameData.Distribution)
+	if err != nil {
+		log.Printf("❌ VERIFY: Game %s has an unknown distribution: %v", gameData.GameID, err)
+		c.JSON(500, gin.H{"error": "failed to load crash distribution"})
+		return
+	}
+
+	result := game.VerifyRound(dist, gameData.Round, gameData.Hash, gameData.ClientSeed, terminatingHash)
+	if result.CrashPoint != gameData.CrashPoint {
+		log.Printf("❌ VERIFY: Game %s stored crash point %.2f, chain gives %.2f",
+			gameData.GameID, gameData.CrashPoint, result.CrashPoint)
+		result.Valid = false
+	}
+	c.JSON(200, result)
 }
 
 func (s *GameServer) GetPlayerGameHistory(c *gin.Context) {
@@ -423,6 +536,7 @@ func (s *GameServer) GetPlayerGameHistory(c *gin.Context) {
 	for rows.Next() {
 		var h struct {
 			GameID            string     `json:"game_id"`
+			Slot              int        `json:"slot"`
 			BetAmount         float64    `json:"bet_amount"`
 			WinAmount         *float64   `json:"win_amount"`
 			CashedOut         bool       `json:"cashed_out"`
@@ -433,10 +547,12 @@ func (s *GameServer) GetPlayerGameHistory(c *gin.Context) {
 			CrashPoint        float64    `json:"crash_point"`
 			Hash              string     `json:"hash"`
 			Status            string     `json:"status"`
+			Cashouts          []byte     `json:"cashouts"`
 		}
 
 		err := rows.Scan(
 			&h.GameID,
+			&h.Slot,
 			&h.BetAmount,
 			&h.WinAmount,
 			&h.CashedOut,
@@ -447,6 +563,7 @@ func (s *GameServer) GetPlayerGameHistory(c *gin.Context) {
 			&h.CrashPoint,
 			&h.Hash,
 			&h.Status,
+			&h.Cashouts,
 		)
 		if err != nil {
 			log.Printf("❌ Row scan failed: %v", err)
@@ -455,6 +572,7 @@ func (s *GameServer) GetPlayerGameHistory(c *gin.Context) {
 
 		history = append(history, gin.H{
 			"game_id":            h.GameID,
+			"slot":               h.Slot,
 			"bet_amount":         h.BetAmount,
 			"win_amount":         h.WinAmount,
 			"cashed_out":         h.CashedOut,
@@ -465,6 +583,7 @@ func (s *GameServer) GetPlayerGameHistory(c *gin.Context) {
 			"crash_point":        h.CrashPoint,
 			"hash":               h.Hash,
 			"status":             h.Status,
+			"cashouts":           json.RawMessage(h.Cashouts),
 		})
 	}
 
@@ -472,60 +591,28 @@ func (s *GameServer) GetPlayerGameHistory(c *gin.Context) {
 	c.JSON(200, history)
 }
 
-func (s *GameServer) endGame() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *GameServer) endGame(r *Room) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	if s.currentGame == nil || s.currentGame.Status != "in_progress" {
-		status := "nil"
-		if s.currentGame != nil {
-			status = s.currentGame.Status
-		}
-		log.Printf("❌ Cannot end game: game=%v, status=%v",
-			s.currentGame != nil, status)
+	if r.currentGame == nil {
+		log.Printf("❌ Cannot end game: no current game")
+		return
+	}
+	if err := r.currentGame.Crash(); err != nil {
+		log.Printf("❌ Cannot end game: %v", err)
 		return
 	}
 
 	log.Printf("🎮 Ending game %s at crash point %.2f",
-		s.currentGame.GameID, s.currentGame.CrashPoint)
-
-	s.currentGame.Status = "crashed"
-	s.currentGame.EndTime = time.Now()
-
-	// Create game history record with initialized Players slice
-	history := &models.GameHistory{
-		GameID:     s.currentGame.GameID,
-		CrashPoint: s.currentGame.CrashPoint,
-		Hash:       s.currentGame.Hash,
-		StartTime:  s.currentGame.StartTime,
-		EndTime:    s.currentGame.EndTime,
-		Status:     "crashed",                                                   // Explicitly set status
-		Players:    make([]models.PlayerHistory, 0, len(s.currentGame.Players)), // Pre-allocate slice
-	}
-
-	// Convert current players to player history
-	for userID, player := range s.currentGame.Players {
-		log.Printf("👤 Processing player %s: Bet=%.2f, Win=%.2f, CashedOut=%v",
-			userID, player.BetAmount, player.WinAmount, player.CashedOut)
-
-		history.Players = append(history.Players, models.PlayerHistory{
-			UserID:      userID,
-			BetAmount:   player.BetAmount,
-			WinAmount:   player.WinAmount,
-			CashedOut:   player.CashedOut,
-			CashoutAt:   player.CashoutAt,
-			AutoCashout: player.AutoCashout,
-		})
-	}
+		r.currentGame.GameID, r.currentGame.CrashPoint)
 
-	// Save game history to database
-	if err := s.db.SaveGameHistory(history); err != nil {
-		log.Printf("❌ Failed to save game history: %v", err)
-	} else {
-		log.Printf("✅ Game history saved - ID: %s, Players: %d",
-			history.GameID, len(history.Players))
-	}
+	s.saveGameToHistory(r)
 
 	// Start new game after delay
-	time.AfterFunc(5*time.Second, s.startNewGame)
+	s.clock.AfterFunc(5*time.Second, func() {
+		if err := s.startNewGame(r); err != nil {
+			log.Printf("❌ Failed to start new game: %v", err)
+		}
+	})
 }
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"crash-game/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reasons a bet is cashed out by the round instead of its player.
+const (
+	ForcedMaxWin    = "max_win"    // the bet reached the max win per bet
+	ForcedMaxPayout = "max_payout" // the round reached its max total payout
+)
+
+// ForcedCashout is a bet the round's payout limits cashed out.
+type ForcedCashout struct {
+	Reason  string
+	Cashout database.Cashout
+}
+
+// winCap returns the multiplier at which the open stake of a bet, with what
+// its partial cashouts won, pays the round's max win, or 0 if wins are not
+// capped. It is never below 1x: stakes above the max win are rejected when
+// the bet is placed, and a bet whose partial cashouts already won up to the
+// max win is cashed out as soon as the round allows.
+func (g *GameState) winCap(p *Player) float64 {
+	open := p.openStake()
+	if g.Config.MaxWin <= 0 || open <= 0 {
+		return 0
+	}
+	return math.Max(1, math.Floor((g.Config.MaxWin-p.WinAmount)/open*100)/100)
+}
+
+// scheduleWinCap schedules the cashout of a bet at its max win, unless its
+// own auto-cashout comes first. An order scheduled before for the bet is
+// left in place and skipped when it comes due.
+func (g *GameState) scheduleWinCap(key string, p *Player) {
+	p.winCap = 0
+	maxWinAt := g.winCap(p)
+	if maxWinAt == 0 || (p.AutoCashout != nil && *p.AutoCashout <= maxWinAt) {
+		return
+	}
+	p.winCap = maxWinAt
+	g.autoCashouts.Add(key, maxWinAt)
+}
+
+// payoutCap returns the multiplier at which the wins paid so far and the
+// value of the open bets reach the round's max payout, or 0 if the payout is
+// not capped or no bet is open. It only rises as bets cash out below it.
+func (g *GameState) payoutCap() float64 {
+	if g.Config.MaxPayout <= 0 || g.openStake <= 0 {
+		return 0
+	}
+	return math.Max(1, math.Floor((g.Config.MaxPayout-g.paid)/g.openStake*100)/100)
+}
+
+// settlePayoutCap cashes out every open bet at the payout cap once the
+// timeline has reached it before the crash.
+func (g *GameState) settlePayoutCap(now time.Time) {
+	capAt := g.payoutCap()
+	if capAt == 0 || capAt >= g.CrashPoint || now.Before(g.MultiplierTime(capAt)) {
+		return
+	}
+
+	at := g.MultiplierTime(capAt)
+	for _, player := range g.Players {
+		if player.CashedOut {
+			continue
+		}
+		g.settle(player, capAt, at)
+		g.forced = append(g.forced, ForcedCashout{
+			Reason:  ForcedMaxPayout,
+			Cashout: g.cashout(player),
+		})
+	}
+}
+
+// TakeForcedCashouts returns the cashouts forced since the last call.
+func (g *GameState) TakeForcedCashouts() []ForcedCashout {
+	forced := g.forced
+	g.forced = nil
+	return forced
+}
+
+// announceForcedCashouts records the cashouts the payout limits forced
+// through the wallet pipeline and tells the room's clients why they
+// happened. The caller must hold the room's mu.
+func (s *GameServer) announceForcedCashouts(g *GameState) {
+	forced := g.TakeForcedCashouts()
+	if len(forced) == 0 {
+		return
+	}
+
+	byReason := make(map[string][]gin.H)
+	for _, f := range forced {
+		s.wallet.RecordCashout(f.Cashout)
+		byReason[f.Reason] = append(byReason[f.Reason], gin.H{
+			"userId":     f.Cashout.UserID,
+			"multiplier": f.Cashout.Multiplier,
+			"winAmount":  f.Cashout.WinAmount,
+		})
+	}
+
+	for reason, cashouts := range byReason {
+		payload := gin.H{
+			"gameId":   g.GameID,
+			"roomId":   g.RoomID,
+			"reason":   reason,
+			"cashouts": cashouts,
+		}
+		switch reason {
+		case ForcedMaxWin:
+			payload["limit"] = g.Config.MaxWin
+			payload["message"] = fmt.Sprintf("Bets were cashed out at the maximum win of %.2f per bet", g.Config.MaxWin)
+		case ForcedMaxPayout:
+			payload["limit"] = g.Config.MaxPayout
+			payload["multiplier"] = cashouts[0]["multiplier"]
+			payload["message"] = fmt.Sprintf("The round reached its maximum payout of %.2f and every open bet was cashed out", g.Config.MaxPayout)
+		}
+
+		log.Printf("🛑 ROUND: %s/%s forced %d cashouts, %s", g.RoomID, g.GameID, len(cashouts), reason)
+		s.broadcastRoomMessage(g.RoomID, WSMessage{Type: "forced_cashout", Payload: payload})
+	}
+}
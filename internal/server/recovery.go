@@ -0,0 +1,150 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"crash-game/internal/database"
+	"crash-game/internal/game"
+	"crash-game/internal/models"
+	"crash-game/internal/round"
+)
+
+// RecoveryPlan is how a round left unfinished by a previous run is closed.
+type RecoveryPlan struct {
+	// Refund is set for rounds that never committed a crash point; every
+	// bet gets its stake back, cashed out or not.
+	Refund bool
+	// Settlement and Cashouts settle a round that had started, as if it had
+	// run to its crash point.
+	Settlement models.Settlement
+	Cashouts   []database.Cashout
+}
+
+// PlanRecovery decides how to close an unfinished round. A round that had
+// started is settled deterministically from its committed crash point: bets
+// that cashed out keep their payout, auto-cashouts below the crash point are
+// paid at their target, and the rest lost. The round's max win and max
+// payout apply as they would have while it ran. Anything else is refunded.
+func PlanRecovery(history models.GameHistory) RecoveryPlan {
+	if (history.Status != "in_progress" && history.Status != "crashed") || history.CrashPoint < 1 {
+		return RecoveryPlan{Refund: true}
+	}
+
+	curve, err := game.ParseCurve(history.Curve)
+	if err != nil {
+		log.Printf("❌ RECOVERY: Round %s has an unknown curve, timing with the default: %v", history.GameID, err)
+		curve = game.DefaultCurve()
+	}
+
+	// Run the round to its crash point on a copy of its bets, with the
+	// cashout schedule and payout limits of a live round
+	g := &GameState{
+		GameID:     history.GameID,
+		RoomID:     history.RoomID,
+		StartTime:  history.StartTime,
+		CrashPoint: history.CrashPoint,
+		Config:     round.Config{Curve: curve, MaxWin: history.MaxWin, MaxPayout: history.MaxPayout},
+		Players:    make(map[string]*Player, len(history.Players)),
+	}
+	g.CrashTime = g.MultiplierTime(g.CrashPoint)
+	g.autoCashouts = round.NewCashoutSchedule()
+	for _, bet := range history.Players {
+		key := betKey(bet.UserID, bet.Slot)
+		player := recoveredPlayer(bet)
+		g.Players[key] = player
+		g.paid += player.WinAmount
+		if player.CashedOut {
+			continue
+		}
+		g.openStake += player.openStake()
+		if player.AutoCashout != nil {
+			g.autoCashouts.Add(key, *player.AutoCashout)
+		}
+		g.scheduleWinCap(key, player)
+	}
+	g.SettleDueCashouts(g.CrashTime)
+
+	if history.EndTime.IsZero() {
+		history.EndTime = g.CrashTime
+	}
+	bets := make([]models.PlayerHistory, 0, len(history.Players))
+	for _, bet := range history.Players {
+		bets = append(bets, g.Players[betKey(bet.UserID, bet.Slot)].record())
+	}
+	history.Players = bets
+
+	settlement, cashouts := PlanSettlement(history)
+	return RecoveryPlan{Settlement: settlement, Cashouts: cashouts}
+}
+
+// recoveredPlayer returns a bet read back from the database as a player of
+// the round, without sharing its cashouts.
+func recoveredPlayer(bet models.PlayerHistory) *Player {
+	return &Player{
+		BetID:       bet.BetID,
+		UserID:      bet.UserID,
+		Slot:        bet.Slot,
+		BetAmount:   bet.BetAmount,
+		CashedOut:   bet.CashedOut,
+		CashoutAt:   bet.CashoutAt,
+		Multiplier:  bet.CashoutMultiplier,
+		WinAmount:   bet.WinAmount,
+		AutoCashout: bet.AutoCashout,
+		ClientSeed:  bet.ClientSeed,
+		Nonce:       bet.Nonce,
+		SeedPairID:  bet.SeedPairID,
+		Cashouts:    append([]models.CashoutLine(nil), bet.Cashouts...),
+	}
+}
+
+// recoverRounds closes the rounds a previous run left unfinished or
+// unsettled and notifies admins of each one.
+func (s *GameServer) recoverRounds() {
+	rounds, err := s.db.GetUnfinishedRounds()
+	if err != nil {
+		log.Printf("❌ RECOVERY: Failed to look up unfinished rounds: %v", err)
+		return
+	}
+
+	for _, round := range rounds {
+		plan := PlanRecovery(round)
+
+		var message string
+		if plan.Refund {
+			refunded, err := s.db.RefundRound(round.GameID, s.clock.Now())
+			if errors.Is(err, database.ErrRoundFinished) {
+				continue
+			}
+			if err != nil {
+				log.Printf("❌ RECOVERY: Failed to refund round %s: %v", round.GameID, err)
+				continue
+			}
+			message = fmt.Sprintf("Round %s in room %s was interrupted before it started; %d bets refunded %.2f",
+				round.GameID, round.RoomID, len(round.Players), refunded)
+		} else {
+			plan.Settlement.Attempts = 1
+			plan.Settlement.SettledAt = s.clock.Now()
+			err := s.db.SettleRound(&plan.Settlement, plan.Cashouts)
+			if errors.Is(err, database.ErrRoundSettled) {
+				continue
+			}
+			if err != nil {
+				log.Printf("❌ RECOVERY: Failed to settle round %s: %v", round.GameID, err)
+				continue
+			}
+			message = fmt.Sprintf("Round %s in room %s was interrupted and settled at %.2fx; %d of %d bets paid %.2f",
+				round.GameID, round.RoomID, round.CrashPoint, plan.Settlement.Winners, plan.Settlement.Bets, plan.Settlement.Paid)
+		}
+
+		log.Printf("🔧 RECOVERY: %s", message)
+		if err := s.notificationManager.CreateNotification(&models.AdminNotification{
+			Type:     "round_recovered",
+			Priority: "high",
+			Message:  message,
+		}); err != nil {
+			log.Printf("❌ RECOVERY: Failed to notify admins: %v", err)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+
+	"crash-game/internal/clock"
+	"crash-game/internal/game"
+	"crash-game/internal/models"
+	"crash-game/internal/round"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultRoomID is the room requests that do not name one are played in.
+const DefaultRoomID = "main"
+
+// maxRoomHistory is how many finished rounds a room keeps in memory.
+const maxRoomHistory = 50
+
+var ErrInvalidRoom = errors.New("invalid room")
+
+var roomIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// defaultRoom is played when no rooms are configured.
+var defaultRoom = models.Room{ID: DefaultRoomID, Name: "Main", MaxBet: 1000}
+
+// Room plays its own sequence of rounds with its own bet limits, round config,
+// history and server seed. A hash revealed in one room belongs to a chain no
+// other room plays, so it gives nothing away about their unfinished rounds.
+type Room struct {
+	models.Room
+
+	seedMu       sync.RWMutex
+	serverSeed   *models.ServerSeed
+	chain        *game.HashChain
+	pendingSeed  *models.ServerSeed
+	pendingChain *game.HashChain
+
+	mu          sync.RWMutex
+	currentGame *GameState
+	history     []models.GameHistory
+	queued      map[string]*Player // bets for the next round, by betKey
+
+	configMu      sync.Mutex
+	roundConfig   round.Config
+	pendingConfig *round.Config
+}
+
+func newRoom(info models.Room, cfg round.Config, clk clock.Clock) *Room {
+	return &Room{
+		Room: info,
+		currentGame: &GameState{
+			RoomID:  info.ID,
+			Machine: round.New("", clk),
+			Players: make(map[string]*Player),
+			Config:  cfg,
+		},
+		history:     make([]models.GameHistory, 0),
+		roundConfig: cfg,
+	}
+}
+
+// ValidateRoom checks that a room has a slug ID and sane bet limits.
+func ValidateRoom(room models.Room) error {
+	switch {
+	case !roomIDPattern.MatchString(room.ID):
+		return fmt.Errorf("%w: id must be 1-32 lowercase letters, digits or dashes", ErrInvalidRoom)
+	case room.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidRoom)
+	case room.MinBet < 0:
+		return fmt.Errorf("%w: minimum bet cannot be negative", ErrInvalidRoom)
+	case room.MaxBet <= 0 || room.MaxBet < room.MinBet:
+		return fmt.Errorf("%w: maximum bet must be positive and at least the minimum bet", ErrInvalidRoom)
+	}
+	return nil
+}
+
+var (
+	errBetAboveMax = errors.New("bet amount exceeds maximum allowed")
+	errBetBelowMin = errors.New("bet amount below minimum allowed")
+)
+
+// checkBetLimits rejects a stake outside the room's bet limits.
+func (r *Room) checkBetLimits(amount float64) error {
+	if amount > r.MaxBet {
+		return errBetAboveMax
+	}
+	if amount < r.MinBet {
+		return errBetBelowMin
+	}
+	return nil
+}
+
+func (r *Room) CurrentGame() *GameState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.currentGame
+}
+
+// recordHistory keeps a finished round in the room's recent history. The
+// caller must hold r.mu.
+func (r *Room) recordHistory(history models.GameHistory) {
+	r.history = append([]models.GameHistory{history}, r.history...)
+	if len(r.history) > maxRoomHistory {
+		r.history = r.history[:maxRoomHistory]
+	}
+}
+
+func (r *Room) recentHistory(limit int) []models.GameHistory {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if limit > len(r.history) {
+		limit = len(r.history)
+	}
+
+	history := make([]models.GameHistory, limit)
+	copy(history, r.history[:limit])
+	return history
+}
+
+// loadRooms sets up the rooms saved in the database, falling back to the
+// default room if none can be loaded.
+func (s *GameServer) loadRooms() []models.Room {
+	rooms, err := s.db.ListRooms()
+	if err != nil {
+		log.Printf("❌ ROOM: Failed to load rooms, playing the default room: %v", err)
+		return []models.Room{defaultRoom}
+	}
+	if len(rooms) == 0 {
+		return []models.Room{defaultRoom}
+	}
+	return rooms
+}
+
+// addRoom registers a room, starting its game loop if rooms are running.
+func (s *GameServer) addRoom(r *Room) {
+	s.roomsMu.Lock()
+	s.rooms = append(s.rooms, r)
+	running := s.roomsRunning
+	s.roomsMu.Unlock()
+
+	if running {
+		go s.gameLoop(r)
+	}
+	log.Printf("🎮 ROOM: %s (%s), bets %.2f-%.2f", r.ID, r.Name, r.MinBet, r.MaxBet)
+}
+
+// Room returns the room with the given ID, or the default room if id is
+// empty. It returns nil if there is no such room.
+func (s *GameServer) Room(id string) *Room {
+	s.roomsMu.RLock()
+	defer s.roomsMu.RUnlock()
+
+	lookup := id
+	if lookup == "" {
+		lookup = DefaultRoomID
+	}
+	for _, r := range s.rooms {
+		if r.ID == lookup {
+			return r
+		}
+	}
+	// Without a main room the first room is the default
+	if id == "" && len(s.rooms) > 0 {
+		return s.rooms[0]
+	}
+	return nil
+}
+
+func (s *GameServer) Rooms() []*Room {
+	s.roomsMu.RLock()
+	defer s.roomsMu.RUnlock()
+	return append([]*Room(nil), s.rooms...)
+}
+
+// startRooms returns the rooms whose game loops the caller must start. Rooms
+// added afterwards start their loop as soon as they are added.
+func (s *GameServer) startRooms() []*Room {
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+
+	s.roomsRunning = true
+	return append([]*Room(nil), s.rooms...)
+}
+
+// playingRoom returns the room whose current round is gameID, if any.
+func (s *GameServer) playingRoom(gameID string) *Room {
+	for _, r := range s.Rooms() {
+		if g := r.CurrentGame(); g != nil && g.GameID == gameID {
+			return r
+		}
+	}
+	return nil
+}
+
+// requestRoom resolves the room named by the roomId query parameter, the
+// default room if the parameter is absent. It responds 404 for an unknown
+// room and returns nil.
+func (s *GameServer) requestRoom(c *gin.Context) *Room {
+	r := s.Room(c.Query("roomId"))
+	if r == nil {
+		c.JSON(404, gin.H{"error": "room not found"})
+	}
+	return r
+}
+
+func (s *GameServer) ListRooms(c *gin.Context) {
+	rooms := make([]gin.H, 0)
+	for _, r := range s.Rooms() {
+		r.mu.RLock()
+		g := r.currentGame
+		rooms = append(rooms, gin.H{
+			"id":     r.ID,
+			"name":   r.Name,
+			"minBet": r.MinBet,
+			"maxBet": r.MaxBet,
+			"gameId": g.GameID,
+			"status": g.State(),
+			"config": g.Config,
+		})
+		r.mu.RUnlock()
+	}
+
+	c.JSON(200, gin.H{"rooms": rooms, "mode": s.Mode()})
+}
+
+func (s *GameServer) CreateRoom(c *gin.Context) {
+	adminID := c.GetInt("adminId")
+
+	// The round config is optional; fields left out take the default values
+	req := struct {
+		ID     string       `json:"id"`
+		Name   string       `json:"name"`
+		MinBet float64      `json:"minBet"`
+		MaxBet float64      `json:"maxBet"`
+		Config round.Config `json:"config"`
+	}{Config: s.roundConfig}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	info := models.Room{ID: req.ID, Name: req.Name, MinBet: req.MinBet, MaxBet: req.MaxBet}
+	if err := ValidateRoom(info); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Config.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if s.Room(info.ID) != nil {
+		c.JSON(400, gin.H{"error": "room already exists"})
+		return
+	}
+
+	if err := s.db.CreateRoom(&info); err != nil {
+		log.Printf("❌ ROOM: Failed to create room %s: %v", info.ID, err)
+		c.JSON(500, gin.H{"error": "failed to create room"})
+		return
+	}
+	if err := s.db.SaveGameSetting(roundConfigKey(info.ID), req.Config); err != nil {
+		log.Printf("❌ CONFIG: Failed to save round config of room %s: %v", info.ID, err)
+	}
+
+	room := newRoom(info, req.Config, s.clock)
+	if err := s.loadServerSeed(room); err != nil {
+		log.Printf("❌ SEED: Failed to create server seed of room %s: %v", info.ID, err)
+		c.JSON(500, gin.H{"error": "failed to create room server seed"})
+		return
+	}
+	s.addRoom(room)
+
+	if err := s.db.LogAdminAction(adminID, "room_create", "room", info.ID, req); err != nil {
+		log.Printf("❌ ROOM: Failed to log admin action: %v", err)
+	}
+
+	c.JSON(200, gin.H{
+		"message": "room created",
+		"room":    info,
+		"config":  req.Config,
+	})
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"database/sql"
+	"log"
+
+	"crash-game/internal/round"
+
+	"github.com/gin-gonic/gin"
+)
+
+// roundConfigKey is the game_settings key the round config of a room is
+// saved under.
+func roundConfigKey(roomID string) string {
+	return "round_config:" + roomID
+}
+
+// loadRoundConfig restores the round config saved by ops for a room, if any.
+func (s *GameServer) loadRoundConfig(r *Room) {
+	cfg := r.roundConfig
+	err := s.db.GetGameSetting(roundConfigKey(r.ID), &cfg)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		log.Printf("❌ CONFIG: Ignoring saved round config of room %s: %v", r.ID, err)
+		return
+	}
+
+	r.roundConfig = cfg
+	r.currentGame.Config = cfg
+	log.Printf("⚙️ CONFIG: Loaded saved round config of room %s, curve %s", r.ID, cfg.Curve.Spec())
+}
+
+// nextRoundConfig returns the config of the round about to start. Updates are
+// applied here, at the round boundary, so a running round never changes pace.
+func (r *Room) nextRoundConfig() round.Config {
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
+
+	if r.pendingConfig != nil {
+		r.roundConfig = *r.pendingConfig
+		r.pendingConfig = nil
+		log.Printf("⚙️ CONFIG: Applied round config of room %s, curve %s", r.ID, r.roundConfig.Curve.Spec())
+	}
+	return r.roundConfig
+}
+
+func (s *GameServer) GetRoundConfig(c *gin.Context) {
+	room := s.requestRoom(c)
+	if room == nil {
+		return
+	}
+
+	room.configMu.Lock()
+	defer room.configMu.Unlock()
+
+	c.JSON(200, gin.H{
+		"roomId":  room.ID,
+		"active":  room.roundConfig,
+		"pending": room.pendingConfig,
+	})
+}
+
+func (s *GameServer) UpdateRoundConfig(c *gin.Context) {
+	adminID := c.GetInt("adminId")
+
+	room := s.requestRoom(c)
+	if room == nil {
+		return
+	}
+
+	// Fields left out of the request keep their scheduled or active values
+	room.configMu.Lock()
+	cfg := room.roundConfig
+	if room.pendingConfig != nil {
+		cfg = *room.pendingConfig
+	}
+	room.configMu.Unlock()
+
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := roundConfigKey(room.ID)
+	if err := s.db.SaveGameSetting(key, cfg); err != nil {
+		log.Printf("❌ CONFIG: Failed to save round config of room %s: %v", room.ID, err)
+		c.JSON(500, gin.H{"error": "failed to save round config"})
+		return
+	}
+
+	room.configMu.Lock()
+	room.pendingConfig = &cfg
+	room.configMu.Unlock()
+
+	if err := s.db.LogAdminAction(adminID, "round_config_update", "round_config", key, cfg); err != nil {
+		log.Printf("❌ CONFIG: Failed to log admin action: %v", err)
+	}
+
+	c.JSON(200, gin.H{
+		"message": "round config will apply from the next round",
+		"roomId":  room.ID,
+		"pending": cfg,
+	})
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"log"
+	"strconv"
+
+	"crash-game/internal/game"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxVerifyRange bounds the rounds checked by one batch verification.
+const maxVerifyRange = 1000
+
+// GetRoundProof returns everything needed to verify a finished round without
+// trusting the server: the chain position and hash, the server seed or its
+// commitment, every bettor's seed contribution and the computed crash point.
+func (s *GameServer) GetRoundProof(c *gin.Context) {
+	gameID := c.Param("id")
+
+	if room := s.playingRoom(gameID); room != nil && !room.CurrentGame().State().Finished() {
+		c.JSON(400, gin.H{"error": "round still in progress"})
+		return
+	}
+
+	gameData, err := s.db.GetGameByID(gameID)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "round not found"})
+		return
+	}
+
+	seed, err := s.db.GetServerSeed(gameData.ServerSeedID)
+	if err != nil {
+		log.Printf("❌ PROOF: Server seed %d lookup failed: %v", gameData.ServerSeedID, err)
+		c.JSON(500, gin.H{"error": "failed to get server seed"})
+		return
+	}
+
+	entries, err := s.db.GetRoundClientSeeds(gameID)
+	if err != nil {
+		log.Printf("❌ PROOF: Client seeds of game %s lookup failed: %v", gameID, err)
+		c.JSON(500, gin.H{"error": "failed to get client seeds"})
+		return
+	}
+
+	check := game.RoundVerifier{TerminatingHash: seed.TerminatingHash}.Verify([]game.RoundRecord{{
+		GameID:       gameData.GameID,
+		Round:        gameData.Round,
+		Hash:         gameData.Hash,
+		ClientSeed:   gameData.ClientSeed,
+		Distribution: gameData.Distribution,
+		CrashPoint:   gameData.CrashPoint,
+	}})[0]
+	clientSeedValid := game.CombineClientSeeds(entries) == gameData.ClientSeed
+
+	c.JSON(200, gin.H{
+		"gameId":             gameData.GameID,
+		"roomId":             gameData.RoomID,
+		"round":              gameData.Round,
+		"hash":               gameData.Hash,
+		"serverSeedId":       seed.ID,
+		"serverSeed":         publicServerSeed(*seed).Seed,
+		"serverSeedHash":     seed.SeedHash,
+		"serverSeedStatus":   seed.Status,
+		"terminatingHash":    seed.TerminatingHash,
+		"chainLength":        seed.ChainLength,
+		"clientSeed":         gameData.ClientSeed,
+		"clientSeeds":        entries,
+		"clientSeedValid":    clientSeedValid,
+		"digest":             game.RoundDigest(gameData.Hash, gameData.ClientSeed),
+		"distribution":       check.Distribution,
+		"crashPoint":         check.CrashPoint,
+		"recordedCrashPoint": gameData.CrashPoint,
+		"chain":              check.Chain,
+		"valid":              check.Pass && clientSeedValid,
+	})
+}
+
+// VerifyRounds checks a range of recorded rounds of one server seed against
+// its hash chain and reports the rounds that do not match. The seed defaults
+// to the active seed of the requested room.
+func (s *GameServer) VerifyRounds(c *gin.Context) {
+	var serverSeedID int
+	if v := c.Query("serverSeedId"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid serverSeedId"})
+			return
+		}
+		serverSeedID = id
+	} else {
+		room := s.requestRoom(c)
+		if room == nil {
+			return
+		}
+		active, _ := room.activeSeed()
+		serverSeedID = active.ID
+	}
+
+	seed, err := s.db.GetServerSeed(serverSeedID)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "server seed not found"})
+		return
+	}
+
+	from, err := strconv.ParseInt(c.DefaultQuery("from", "1"), 10, 64)
+	if err != nil || from < 1 {
+		c.JSON(400, gin.H{"error": "invalid from"})
+		return
+	}
+	to, err := strconv.ParseInt(c.DefaultQuery("to", strconv.FormatInt(from+maxVerifyRange-1, 10)), 10, 64)
+	if err != nil || to < from {
+		c.JSON(400, gin.H{"error": "invalid to"})
+		return
+	}
+	if to-from+1 > maxVerifyRange {
+		c.JSON(400, gin.H{"error": "range too large, at most " + strconv.Itoa(maxVerifyRange) + " rounds"})
+		return
+	}
+
+	games, err := s.db.GetGamesByRound(seed.ID, from, to)
+	if err != nil {
+		log.Printf("❌ VERIFY: Failed to load rounds %d-%d of seed %d: %v", from, to, seed.ID, err)
+		c.JSON(500, gin.H{"error": "failed to get rounds"})
+		return
+	}
+
+	records := make([]game.RoundRecord, 0, len(games))
+	for _, g := range games {
+		records = append(records, game.RoundRecord{
+			GameID:       g.GameID,
+			Round:        g.Round,
+			Hash:         g.Hash,
+			ClientSeed:   g.ClientSeed,
+			Distribution: g.Distribution,
+			CrashPoint:   g.CrashPoint,
+		})
+	}
+
+	mismatches := make([]game.RoundCheck, 0)
+	for _, check := range (game.RoundVerifier{TerminatingHash: seed.TerminatingHash}).Verify(records) {
+		if !check.Pass {
+			mismatches = append(mismatches, check)
+		}
+	}
+	if len(mismatches) > 0 {
+		log.Printf("❌ VERIFY: %d of %d rounds of seed %d failed verification", len(mismatches), len(records), seed.ID)
+	}
+
+	c.JSON(200, gin.H{
+		"serverSeedId":    seed.ID,
+		"terminatingHash": seed.TerminatingHash,
+		"from":            from,
+		"to":              to,
+		"checked":         len(records),
+		"passed":          len(records) - len(mismatches),
+		"failed":          len(mismatches),
+		"mismatches":      mismatches,
+	})
+}
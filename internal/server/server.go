@@ -2,17 +2,18 @@ package server
 
 import (
 	"errors"
-	"math"
-	"math/rand"
 	"sync"
 	"time"
 
+	"crash-game/internal/clock"
 	"crash-game/internal/database"
+	"crash-game/internal/game"
 	"crash-game/internal/models"
 	"crash-game/internal/notification"
+	"crash-game/internal/round"
 	"crash-game/internal/security"
+	"crash-game/internal/wallet"
 
-	"crypto/sha256"
 	"fmt"
 
 	"log"
@@ -22,24 +23,49 @@ import (
 )
 
 type GameState struct {
-	GameID     string             `json:"gameId"`
-	StartTime  time.Time          `json:"startTime"`
-	CrashPoint float64            `json:"-"`
-	Status     string             `json:"status"` // "waiting", "in_progress", "crashed"
-	Players    map[string]*Player `json:"players"`
-	Elapsed    float64            `json:"elapsed"`
-	Hash       string             `json:"hash"`
-	Saved      bool               `json:"-"`
-	EndTime    time.Time          `json:"endTime"`
+	GameID         string    `json:"gameId"`
+	RoomID         string    `json:"roomId"`
+	ServerSeedID   int       `json:"serverSeedId"`
+	Round          int64     `json:"round"`
+	StartTime      time.Time `json:"startTime"`
+	CrashPoint     float64   `json:"-"`
+	CrashTime      time.Time `json:"-"` // instant the round crashes, fixed at start
+	autoCashouts   *round.CashoutSchedule
+	openStake      float64         // stakes of the bets not cashed out yet
+	paid           float64         // wins of the bets cashed out so far
+	forced         []ForcedCashout // forced cashouts not announced yet
+	*round.Machine `json:"-"`
+	Players        map[string]*Player     `json:"players"` // by betKey
+	reserved       map[string]bool        // bets being debited, by betKey
+	placements     sync.WaitGroup         // debits still in flight
+	Elapsed        float64                `json:"elapsed"`
+	Hash           string                 `json:"-"`          // revealed once the round has crashed
+	ClientSeed     string                 `json:"clientSeed"` // set when betting closes
+	Distribution   game.CrashDistribution `json:"-"`
+	Config         round.Config           `json:"-"`
+	Saved          bool                   `json:"-"`
+	EndTime        time.Time              `json:"endTime"`
 }
 
 type Player struct {
+	BetID       int        `json:"betId,omitempty"`
 	UserID      string     `json:"userId"`
+	Slot        int        `json:"slot"`
 	BetAmount   float64    `json:"betAmount"`
 	CashedOut   bool       `json:"cashedOut"`
 	CashoutAt   *time.Time `json:"cashoutAt,omitempty"`
+	Multiplier  float64    `json:"multiplier,omitempty"`
 	WinAmount   float64    `json:"winAmount"`
 	AutoCashout *float64   `json:"autoCashout,omitempty"`
+	ClientSeed  string     `json:"clientSeed,omitempty"`
+	Nonce       int64      `json:"nonce"`
+	SeedPairID  int        `json:"-"`
+	// Cashouts are the cashouts of the bet in order; a bet can be cashed out
+	// in parts and stays open until its whole stake is cashed out
+	Cashouts []models.CashoutLine `json:"cashouts,omitempty"`
+	// winCap is the multiplier the bet is cashed out at to stay within the
+	// round's max win, when that comes before its own auto-cashout
+	winCap float64
 }
 
 type GameHistory struct {
@@ -62,29 +88,84 @@ type PlayerHistory struct {
 
 type GameServer struct {
 	router              *gin.Engine
-	currentGame         *GameState
-	mu                  sync.RWMutex
+	roomsMu             sync.RWMutex
+	rooms               []*Room
+	roomsRunning        bool
+	fixedRooms          []models.Room
 	db                  *database.Database
-	historyMu           sync.RWMutex
-	gameHistory         []models.GameHistory
+	wallet              *wallet.Pipeline
+	walletStore         wallet.Store
+	distribution        game.CrashDistribution
+	clock               clock.Clock
+	roundConfig         round.Config
+	roundConfigFixed    bool
+	modeMu              sync.Mutex
+	mode                GameMode
+	autoBetsMu          sync.Mutex
+	autoBets            map[string]*autoBet // by user
 	notificationManager *notification.NotificationManager
 	csrfManager         *security.CSRFManager
 	clients             sync.Map
 	baseURL             string
 }
 
-func NewGameServer(db *database.Database) *GameServer {
+func NewGameServer(db *database.Database, opts ...Option) *GameServer {
 	router := gin.Default()
 
 	server := &GameServer{
-		db:          db,
-		router:      router,
-		gameHistory: make([]models.GameHistory, 0),
-		clients:     sync.Map{},
-		currentGame: &GameState{
-			Status:  "betting",
-			Players: make(map[string]*Player),
-		},
+		db:           db,
+		router:       router,
+		distribution: game.DefaultDistribution(),
+		clock:        clock.Real(),
+		roundConfig:  round.DefaultConfig(),
+		clients:      sync.Map{},
+
+		notificationManager: notification.NewNotificationManager(db),
+	}
+
+	for _, opt := range opts {
+		opt(server)
+	}
+
+	// Balance changes are applied by the wallet pipeline, off the round locks
+	if server.walletStore == nil {
+		server.walletStore = db
+	}
+	server.wallet = wallet.New(server.walletStore, walletWorkers)
+
+	log.Printf("🎲 Crash distribution: %s", server.distribution.Spec())
+
+	// Stay paused or in maintenance across restarts
+	server.loadGameMode()
+
+	// Settle or refund the rounds a previous run left unfinished before
+	// any new round is played
+	server.recoverRounds()
+
+	// Play the rooms saved in the database unless WithRooms fixed them
+	rooms := server.fixedRooms
+	if len(rooms) == 0 {
+		rooms = server.loadRooms()
+	}
+	// Fixed rooms are recorded too, as their rounds and seeds refer to them
+	for _, info := range server.fixedRooms {
+		if err := db.EnsureRoom(info); err != nil {
+			log.Printf("❌ ROOM: Failed to record room %s: %v", info.ID, err)
+		}
+	}
+	for _, info := range rooms {
+		room := newRoom(info, server.roundConfig, server.clock)
+		// Round timings set through WithRoundConfig take precedence over the
+		// persisted admin configuration
+		if !server.roundConfigFixed {
+			server.loadRoundConfig(room)
+		}
+		// Resume the hash chain of the room's active server seed; rounds
+		// cannot be recorded or verified without a committed seed
+		if err := server.loadServerSeed(room); err != nil {
+			log.Fatalf("❌ SEED: Failed to load server seed of room %s: %v", room.ID, err)
+		}
+		server.addRoom(room)
 	}
 
 	// Setup routes
@@ -94,11 +175,10 @@ func NewGameServer(db *database.Database) *GameServer {
 }
 
 func (s *GameServer) Run(addr string) error {
-	// Initialize first game
-	s.startNewGame()
-
-	// Start the game loop in a goroutine
-	go s.gameLoop()
+	// Start the game loop of every room
+	for _, room := range s.startRooms() {
+		go s.gameLoop(room)
+	}
 
 	// Log server startup
 	log.Printf("Server starting on %s", addr)
@@ -107,184 +187,387 @@ func (s *GameServer) Run(addr string) error {
 	return s.router.Run(addr)
 }
 
-func (s *GameServer) gameLoop() {
+// startRoundAttempts is how often committing the crash point of a round is
+// tried before the round is refunded instead of played.
+const startRoundAttempts = 3
+
+// walletWorkers is how many users' balance changes are applied at once.
+const walletWorkers = 16
+
+// startRetryDelay is how long the game loop backs off when a round cannot
+// be started.
+const startRetryDelay = 2 * time.Second
+
+func (s *GameServer) gameLoop(r *Room) {
 	for {
+		// Ops may hold the rooms between rounds
+		s.waitWhilePaused(r)
+
 		// Start new game
-		s.startNewGame()
+		if err := s.startNewGame(r); err != nil {
+			log.Printf("❌ Failed to start new game: %v", err)
+			s.clock.Sleep(startRetryDelay)
+			continue
+		}
+		cfg := r.CurrentGame().Config
 
-		// Betting phase (5 seconds)
-		log.Printf("⏳ Betting phase started")
-		time.Sleep(5 * time.Second)
+		// Betting phase
+		log.Printf("⏳ Betting phase started in room %s", r.ID)
+		s.clock.Sleep(cfg.BettingDuration)
 
 		// Game phase
-		s.mu.Lock()
-		if s.currentGame != nil {
-			s.currentGame.Status = "in_progress"
-			gameID := s.currentGame.GameID
-			crashPoint := s.currentGame.CrashPoint
-			log.Printf("🎮 DEBUG: [GAME] Starting game %s", s.currentGame.GameID)
-
-			// Log all players and their auto-cashouts at game start
-			for userID, player := range s.currentGame.Players {
-				autoCashoutValue := "<nil>"
-				if player.AutoCashout != nil {
-					autoCashoutValue = fmt.Sprintf("%.2f", *player.AutoCashout)
-				}
-				log.Printf("👤 DEBUG: [GAME] Player %s starting with AutoCashout: %s",
-					userID, autoCashoutValue)
+		r.mu.Lock()
+		g := r.currentGame
+		err := g.CloseBetting()
+		r.mu.Unlock()
+		if err != nil {
+			log.Printf("❌ ROUND: %v", err)
+			s.clock.Sleep(cfg.CooldownDuration)
+			continue
+		}
+
+		// Bets placed before betting closed finish their debit first
+		g.placements.Wait()
+
+		// Betting is closed, so the client seeds of the round are final
+		r.mu.Lock()
+		g.ClientSeed = roundClientSeed(g.Players)
+		g.CrashPoint = g.Distribution.CrashPoint(game.RoundDigest(g.Hash, g.ClientSeed))
+		r.mu.Unlock()
+
+		// Commit the crash point before any cashout can be recorded, without
+		// holding up the room while the database answers. A round whose
+		// crash point cannot be committed is refunded instead of played.
+		start, err := s.commitRoundStart(g)
+		if err != nil {
+			log.Printf("❌ ROUND: Failed to record start of %s, refunding it: %v", g.GameID, err)
+			s.refundUnstartedRound(r, g)
+			s.clock.Sleep(cfg.CooldownDuration)
+			continue
+		}
+
+		r.mu.Lock()
+		g.StartTimeline(start)
+		if err := g.Start(); err != nil {
+			log.Printf("❌ ROUND: %v", err)
+		}
+		crashPoint := g.CrashPoint
+
+		// Log all players and their auto-cashouts at game start
+		for userID, player := range g.Players {
+			autoCashoutValue := "<nil>"
+			if player.AutoCashout != nil {
+				autoCashoutValue = fmt.Sprintf("%.2f", *player.AutoCashout)
+			}
+			log.Printf("👤 DEBUG: [GAME] Player %s starting with AutoCashout: %s",
+				userID, autoCashoutValue)
+		}
+		r.mu.Unlock()
+
+		// Sleep until the next auto-cashout target or the crash instant,
+		// whichever comes first, waking at least every tick
+		for {
+			now := s.clock.Now()
+			log.Printf("🎲 DEBUG: [GAME] Current multiplier: %.2fx", g.MultiplierAt(now))
+
+			// Auto-cashouts are paid out when the round is settled; cashouts
+			// forced by the payout limits are recorded and announced at once
+			r.mu.Lock()
+			g.SettleDueCashouts(now)
+			s.announceForcedCashouts(g)
+			wakeup := g.nextWakeup()
+			r.mu.Unlock()
+
+			// Check if we should crash
+			if !now.Before(g.CrashTime) {
+				log.Printf("💥 DEBUG: [GAME] Crashing at %.2fx", crashPoint)
+				break
 			}
-			s.mu.Unlock()
-
-			// Wait until crash, checking auto-cashouts periodically
-			start := time.Now()
-			ticker := time.NewTicker(100 * time.Millisecond)
-			defer ticker.Stop()
-
-			for {
-				elapsed := time.Since(start).Seconds()
-				multiplier := math.Pow(math.E, 0.1*elapsed)
-
-				log.Printf("🎲 DEBUG: [GAME] Current multiplier: %.2fx", multiplier)
-
-				// Check if we should crash
-				if multiplier >= crashPoint {
-					log.Printf("💥 DEBUG: [GAME] Crashing at %.2fx", multiplier)
-					break
-				}
-
-				// Check auto-cashouts
-				s.mu.Lock()
-				for userID, player := range s.currentGame.Players {
-					autoCashoutValue := "<nil>"
-					if player.AutoCashout != nil {
-						autoCashoutValue = fmt.Sprintf("%.2f", *player.AutoCashout)
-					}
-					log.Printf("👤 DEBUG: [AUTO] Player %s check - AutoCashout: %s, CashedOut: %v, Multiplier: %.2fx, Pointer: %p",
-						userID, autoCashoutValue, player.CashedOut, multiplier, player.AutoCashout)
-
-					if !player.CashedOut && player.AutoCashout != nil {
-						targetMultiplier := *player.AutoCashout
-						log.Printf("🎯 DEBUG: [AUTO] Comparing %.2f >= %.2f for user %s",
-							multiplier, targetMultiplier, userID)
-
-						if multiplier >= targetMultiplier {
-							log.Printf("💰 DEBUG: [AUTO] TRIGGER - User %s at %.2fx (target: %.2fx)",
-								userID, multiplier, *player.AutoCashout)
-							player.CashedOut = true
-							player.WinAmount = player.BetAmount * multiplier
-							now := time.Now()
-							player.CashoutAt = &now
-
-							// Credit winnings
-							if err := s.db.UpdateBalance(userID, player.WinAmount, "credit"); err != nil {
-								log.Printf("❌ DEBUG: [AUTO] Failed to credit auto-cashout: %v", err)
-							} else {
-								log.Printf("✅ DEBUG: [AUTO] Success - User: %s, Amount: %.2f at %.2fx",
-									userID, player.WinAmount, multiplier)
-							}
-						}
-					}
-				}
-				s.mu.Unlock()
-
-				<-ticker.C
+
+			wait := wakeup.Sub(now)
+			if wait > cfg.TickInterval {
+				wait = cfg.TickInterval
 			}
+			s.clock.Sleep(wait)
+		}
 
-			// End game and save
-			s.mu.Lock()
-			s.currentGame.Status = "crashed"
-			log.Printf("💥 Game crashed - ID: %s at %.2fx", gameID, crashPoint)
-			s.saveGameToHistory()
-			s.mu.Unlock()
-		} else {
-			s.mu.Unlock()
+		// End game and save
+		r.mu.Lock()
+		if err := g.Crash(); err != nil {
+			log.Printf("❌ ROUND: %v", err)
 		}
+		log.Printf("💥 Game crashed - ID: %s at %.2fx", g.GameID, crashPoint)
+		s.announceForcedCashouts(g)
+		s.saveGameToHistory(r)
+		s.recordAutoBets(r, g)
+		r.mu.Unlock()
 
 		// Short delay between games
-		time.Sleep(2 * time.Second)
+		s.clock.Sleep(cfg.CooldownDuration)
 	}
 }
 
-func (s *GameServer) startNewGame() {
-	gameID := uuid.New().String()
-	hash := generateHash(gameID)
-	crashPoint := generateCrashPoint()
-
-	s.mu.Lock()
-	s.currentGame = &GameState{
-		GameID:     gameID,
-		StartTime:  time.Now().Add(5 * time.Second),
-		CrashPoint: crashPoint,
-		Status:     "betting",
-		Players:    make(map[string]*Player),
-		Hash:       hash,
-	}
-	s.mu.Unlock()
-
-	log.Printf("🎮 NEW GAME - ID: %s", gameID)
-	log.Printf("🎲 Game details - Hash: %s, CrashPoint: %.2f", hash, crashPoint)
+// commitRoundStart commits the crash point of a round whose betting closed,
+// retrying with backoff, and returns the time the round starts at.
+func (s *GameServer) commitRoundStart(g *GameState) (time.Time, error) {
+	var err error
+	for attempt := 1; attempt <= startRoundAttempts; attempt++ {
+		start := s.clock.Now()
+		err = s.db.StartRound(g.GameID, g.ClientSeed, g.CrashPoint, start)
+		if err == nil {
+			return start, nil
+		}
+
+		log.Printf("❌ ROUND: Attempt %d to record start of %s failed: %v", attempt, g.GameID, err)
+		if attempt < startRoundAttempts {
+			s.clock.Sleep(SettleBackoff(attempt))
+		}
+	}
+	return time.Time{}, err
 }
 
-func generateHash(gameID string) string {
-	data := []byte(gameID)
-	hash := sha256.Sum256(data)
-	return fmt.Sprintf("%x", hash)
+// refundUnstartedRound refunds the bets of a round that could not be started.
+// A round that cannot be refunded either stays recorded as betting, and is
+// refunded by recovery at the next start.
+func (s *GameServer) refundUnstartedRound(r *Room, g *GameState) {
+	refunded, err := s.db.RefundRound(g.GameID, s.clock.Now())
+
+	var message string
+	if err != nil {
+		message = fmt.Sprintf("Round %s in room %s could not be started nor refunded; its %d bets are refunded by recovery at the next start: %v",
+			g.GameID, r.ID, len(g.Players), err)
+	} else {
+		message = fmt.Sprintf("Round %s in room %s could not be started; %d bets refunded %.2f",
+			g.GameID, r.ID, len(g.Players), refunded)
+	}
+	log.Printf("❌ ROUND: %s", message)
+
+	s.broadcastRoomMessage(r.ID, WSMessage{
+		Type:    "round_refunded",
+		Payload: gin.H{"gameId": g.GameID, "roomId": r.ID, "refunded": err == nil},
+	})
+	if err := s.notificationManager.CreateNotification(&models.AdminNotification{
+		Type:     "round_start_failed",
+		Priority: "high",
+		Message:  message,
+	}); err != nil {
+		log.Printf("❌ ROUND: Failed to notify admins: %v", err)
+	}
 }
 
-func generateCrashPoint() float64 {
-	return 1.0 + rand.Float64()*9.0
+// placeBet places a player's bet in the room's betting round. The player is
+// reserved under the room lock and joins the round once the wallet pipeline
+// has debited the stake and recorded the bet with the next nonce of the
+// player's seed pair, so the database holds up no other bet, cashout or tick.
+func (s *GameServer) placeBet(r *Room, player *Player) error {
+	if err := s.checkAcceptingBets(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	g := r.currentGame
+	err := g.reserveBet(player)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	bet := player.record()
+	err = s.wallet.PlaceBet(g.GameID, g.ServerSeedID, &bet)
+	if err == nil {
+		player.BetID = bet.BetID
+		player.SeedPairID = bet.SeedPairID
+		player.ClientSeed = bet.ClientSeed
+		player.Nonce = bet.Nonce
+	}
+
+	r.mu.Lock()
+	g.confirmBet(player, err == nil)
+	r.mu.Unlock()
+	return err
 }
 
-// Initialize random seed in init
-func init() {
-	rand.Seed(time.Now().UnixNano())
+// cancelBet cancels a player's bet in a slot while the room's round still
+// takes bets and refunds its stake. The bet leaves the round under the room
+// lock, so it either is cancelled before betting closes or not at all; the
+// round waits for the refund before it starts. A bet queued for the next
+// round is dropped from the queue instead, with nothing to refund; queued
+// reports which.
+func (s *GameServer) cancelBet(r *Room, userID string, slot int) (player *Player, queued bool, err error) {
+	key := betKey(userID, slot)
+
+	r.mu.Lock()
+	if player, queued := r.queued[key]; queued {
+		delete(r.queued, key)
+		r.mu.Unlock()
+		log.Printf("↩️ BET: Queued bet of %s in slot %d cancelled", userID, slot)
+		return player, true, nil
+	}
+	g := r.currentGame
+	player, err = g.cancelBet(key)
+	r.mu.Unlock()
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = s.wallet.CancelBet(player.record(), s.clock.Now())
+
+	r.mu.Lock()
+	g.confirmCancel(player, err == nil)
+	r.mu.Unlock()
+	if err != nil {
+		return nil, false, err
+	}
+
+	log.Printf("↩️ BET: Bet %d of %s in slot %d cancelled, %.2f refunded", player.BetID, userID, slot, player.BetAmount)
+	return player, false, nil
 }
 
-func (s *GameServer) saveGameToHistory() {
-	if s.currentGame == nil {
-		return
+// cashoutPlayer cashes portion of a player's bet in a slot out of the room's
+// running round. Only the round in memory changes under the room lock; the
+// cashout is recorded through the wallet pipeline without waiting for the
+// database.
+func (s *GameServer) cashoutPlayer(r *Room, userID string, slot int, portion cashoutPortion) (database.Cashout, error) {
+	r.mu.Lock()
+	cashout, err := r.currentGame.cashoutPlayer(betKey(userID, slot), portion, s.clock.Now())
+	r.mu.Unlock()
+	if err != nil {
+		return cashout, err
 	}
 
-	// Add a saved flag check
-	if s.currentGame.Saved {
-		log.Printf("Game %s already saved, skipping", s.currentGame.GameID)
-		return
+	s.wallet.RecordCashout(cashout)
+	return cashout, nil
+}
+
+func (s *GameServer) startNewGame(r *Room) error {
+	gameID := uuid.New().String()
+	cfg := r.nextRoundConfig()
+	serverSeedID, roundNumber, hash, err := s.nextRoundHash(r)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("💾 SAVE: Game %s - Hash: %s", s.currentGame.GameID, s.currentGame.Hash)
+	g := &GameState{
+		GameID:       gameID,
+		RoomID:       r.ID,
+		ServerSeedID: serverSeedID,
+		Round:        roundNumber,
+		StartTime:    s.clock.Now().Add(cfg.BettingDuration),
+		Players:      make(map[string]*Player),
+		Hash:         hash,
+		Distribution: s.distribution,
+		Config:       cfg,
+	}
+	g.Machine = round.New(gameID, s.clock, s.roundListener(g))
 
-	// Mark as saved before doing the actual save
-	s.currentGame.Saved = true
+	// Record the round before taking bets, so they can be recovered
+	if err := s.db.CreateRound(g.record("betting")); err != nil {
+		return fmt.Errorf("failed to record round: %w", err)
+	}
 
-	// Create game history with players
-	history := &models.GameHistory{
-		GameID:     s.currentGame.GameID,
-		CrashPoint: s.currentGame.CrashPoint,
-		StartTime:  s.currentGame.StartTime,
-		EndTime:    time.Now(),
-		Hash:       s.currentGame.Hash,
-		Status:     "crashed",
-		Players:    make([]models.PlayerHistory, 0, len(s.currentGame.Players)),
+	r.mu.Lock()
+	r.currentGame = g
+	err = g.OpenBetting()
+	queued := r.queued
+	r.queued = nil
+	r.mu.Unlock()
+	if err != nil {
+		return err
 	}
 
-	// Add players to history
-	for userID, player := range s.currentGame.Players {
-		history.Players = append(history.Players, models.PlayerHistory{
-			UserID:      userID,
-			BetAmount:   player.BetAmount,
-			WinAmount:   player.WinAmount,
-			CashedOut:   player.CashedOut,
-			CashoutAt:   player.CashoutAt,
-			AutoCashout: player.AutoCashout,
-		})
+	log.Printf("🎮 NEW GAME - ID: %s, Room: %s, Seed: %d, Round: %d", gameID, r.ID, serverSeedID, roundNumber)
+	log.Printf("🎲 Game details - Hash: %s", hash)
+
+	if len(queued) > 0 {
+		go s.placeQueuedBets(r, g, queued)
+	}
+	if sessions := s.roomAutoBets(r.ID); len(sessions) > 0 {
+		s.placeAutoBets(r, g, sessions, queued)
+	}
+	return nil
+}
+
+// queueBet holds a bet for the room's next round; it is placed as soon as
+// betting opens. It returns errBettingOpen if the current round takes bets,
+// as the bet can be placed at once.
+func (s *GameServer) queueBet(r *Room, player *Player) error {
+	if err := s.checkAcceptingBets(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.currentGame.Require(round.Betting) == nil {
+		return errBettingOpen
+	}
+	// The slot and stake are checked again against the next round's config
+	if player.Slot < 0 || player.Slot >= r.currentGame.Config.BetSlots {
+		return errInvalidSlot
+	}
+	if maxWin := r.currentGame.Config.MaxWin; maxWin > 0 && player.BetAmount > maxWin {
+		return errAboveMaxWin
+	}
+	key := betKey(player.UserID, player.Slot)
+	if _, exists := r.queued[key]; exists {
+		return errBetQueued
+	}
+
+	if r.queued == nil {
+		r.queued = make(map[string]*Player)
+	}
+	r.queued[key] = player
+	log.Printf("⏭️ BET: Bet of %s in slot %d queued for the next round of %s, amount: %.2f",
+		player.UserID, player.Slot, r.ID, player.BetAmount)
+	return nil
+}
+
+// placeQueuedBets places the bets queued for a round that just opened
+// betting and tells each player how their bet fared.
+func (s *GameServer) placeQueuedBets(r *Room, g *GameState, queued map[string]*Player) {
+	for _, player := range queued {
+		err := s.placeBet(r, player)
+
+		result := gin.H{
+			"gameId": g.GameID,
+			"roomId": r.ID,
+			"slot":   player.Slot,
+			"amount": player.BetAmount,
+			"placed": err == nil,
+		}
+		if err == nil {
+			result["betId"] = player.BetID
+		} else {
+			log.Printf("❌ BET: Failed to place queued bet of %s in round %s: %v", player.UserID, g.GameID, err)
+			result["error"] = err.Error()
+		}
+		s.sendToUser(player.UserID, WSMessage{Type: "queued_bet", Payload: result})
 	}
+}
 
-	if err := s.db.SaveGameHistory(history); err != nil {
-		log.Printf("❌ SAVE: Failed to save game: %v", err)
+// saveGameToHistory records the finished round in the room's history and
+// settles it in the background. The caller must hold r.mu.
+func (s *GameServer) saveGameToHistory(r *Room) {
+	if r.currentGame == nil {
+		return
+	}
+
+	// Add a saved flag check
+	if r.currentGame.Saved {
+		log.Printf("Game %s already saved, skipping", r.currentGame.GameID)
 		return
 	}
+	r.currentGame.Saved = true
 
-	log.Printf("✅ SAVE: Game saved successfully with %d players", len(history.Players))
+	log.Printf("💾 SAVE: Game %s - Hash: %s", r.currentGame.GameID, r.currentGame.Hash)
+
+	history := r.currentGame.record("crashed")
+	history.EndTime = r.currentGame.CrashTime
+	r.recordHistory(*history)
+
+	// The database is written by the settlement, outside the game loop, so
+	// neither a slow query nor retries hold up the next round
+	go s.settleRound(r, r.currentGame, *history)
 }
 
 func (s *GameServer) setupRoutes() {
@@ -293,9 +576,19 @@ func (s *GameServer) setupRoutes() {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Round events and seed rotations are pushed to WebSocket clients
+	s.router.GET("/ws", s.handleWebSocket)
+
 	// API routes
 	api := s.router.Group("/api")
 	{
+		// Public fairness routes
+		api.GET("/fairness/chain", s.GetHashChain)
+		api.GET("/fairness/seeds", s.ListServerSeeds)
+		api.GET("/rounds/verify", s.VerifyRounds)
+		api.GET("/rounds/:id/proof", s.GetRoundProof)
+		api.GET("/rooms", s.ListRooms)
+
 		// Auth routes
 		auth := api.Group("/auth")
 		{
@@ -309,31 +602,68 @@ func (s *GameServer) setupRoutes() {
 		{
 			authenticated.GET("/user/balance", s.GetBalance)
 			authenticated.POST("/bet", s.PlaceBet)
+			authenticated.DELETE("/bet", s.CancelBet)
+			authenticated.POST("/autobet", s.StartAutoBet)
+			authenticated.GET("/autobet", s.GetAutoBet)
+			authenticated.DELETE("/autobet", s.StopAutoBet)
 			authenticated.POST("/cashout", s.Cashout)
 			authenticated.GET("/game/current", s.GetCurrentGame)
 			authenticated.GET("/game/history", s.GetGameHistory)
 			authenticated.GET("/game/player/history", s.GetPlayerGameHistory)
 			authenticated.POST("/game/verify", s.VerifyGameFairness)
 			authenticated.POST("/user/withdraw", s.RequestWithdrawal)
+			authenticated.GET("/fairness/seed", s.GetSeedPair)
+			authenticated.PUT("/fairness/client-seed", s.SetClientSeed)
+		}
+
+		// Admin routes
+		api.POST("/admin/login", s.AdminLogin)
+		admin := api.Group("/admin")
+		admin.Use(AdminAuthMiddleware(), s.adminMiddleware())
+		{
+			admin.POST("/fairness/seed/rotate", s.RotateServerSeed)
+			admin.GET("/fairness/audit", s.GetFairnessAudit)
+			admin.GET("/round-config", s.GetRoundConfig)
+			admin.PUT("/round-config", s.UpdateRoundConfig)
+			admin.POST("/rooms", s.CreateRoom)
+			admin.GET("/game/mode", s.GetGameMode)
+			admin.POST("/game/pause", s.PauseGame)
+			admin.POST("/game/resume", s.ResumeGame)
+			admin.POST("/game/maintenance", s.StartMaintenance)
+			admin.POST("/rounds/:id/void", s.HandleVoidRound)
 		}
 	}
 }
 
+// CurrentGame returns the current round of the default room.
 func (s *GameServer) CurrentGame() *GameState {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.currentGame
+	r := s.Room("")
+	if r == nil {
+		return nil
+	}
+	return r.CurrentGame()
 }
 
 func (s *GameServer) PlaceBetForTest(userID string, amount float64, autoCashout *float64) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	r := s.Room("")
+	if r == nil {
+		return errors.New("game not accepting bets")
+	}
+	if err := s.checkAcceptingBets(); err != nil {
+		return err
+	}
 
-	if s.currentGame == nil || s.currentGame.Status != "betting" {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.currentGame == nil {
 		return errors.New("game not accepting bets")
 	}
+	if err := r.currentGame.Require(round.Betting); err != nil {
+		return err
+	}
 
-	s.currentGame.Players[userID] = &Player{
+	r.currentGame.Players[userID] = &Player{
 		UserID:      userID,
 		BetAmount:   amount,
 		AutoCashout: autoCashout,
@@ -343,111 +673,86 @@ func (s *GameServer) PlaceBetForTest(userID string, amount float64, autoCashout
 }
 
 func (s *GameServer) CashoutForTest(userID string) (float64, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.currentGame == nil || s.currentGame.Status != "in_progress" {
+	r := s.Room("")
+	if r == nil {
 		return 0, errors.New("game not in progress")
 	}
 
-	player, exists := s.currentGame.Players[userID]
-	if !exists {
-		return 0, errors.New("no bet found for this game")
-	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	if player.CashedOut {
-		return 0, errors.New("already cashed out")
-	}
+	cashout, err := r.currentGame.cashoutPlayer(userID, cashoutPortion{}, s.clock.Now())
+	return cashout.Multiplier, err
+}
 
-	multiplier := s.currentGame.GetCurrentMultiplier()
-	player.CashedOut = true
-	player.WinAmount = player.BetAmount * multiplier
+// CashoutDirect is for testing - cashes out like the cashout handler,
+// recording the cashout through the wallet pipeline
+func (s *GameServer) CashoutDirect(userID string) (float64, error) {
+	r := s.Room("")
+	if r == nil {
+		return 0, errNoActiveGame
+	}
 
-	return multiplier, nil
+	cashout, err := s.cashoutPlayer(r, userID, 0, cashoutPortion{})
+	return cashout.Multiplier, err
 }
 
 func (s *GameServer) GetGameHistoryInMemory(limit int) ([]models.GameHistory, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if limit > len(s.gameHistory) {
-		limit = len(s.gameHistory)
-	}
-
-	history := make([]models.GameHistory, limit)
-	copy(history, s.gameHistory[:limit])
-	return history, nil
+	return s.GetRecentGames(limit)
 }
 
 func (s *GameServer) GetGameHistoryForTest(limit int) ([]models.GameHistory, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if limit > len(s.gameHistory) {
-		limit = len(s.gameHistory)
-	}
-
-	history := make([]models.GameHistory, limit)
-	copy(history, s.gameHistory[:limit])
-	return history, nil
+	return s.GetRecentGames(limit)
 }
 
-// PlaceBetDirect is for testing - allows direct bet placement without gin context
+// PlaceBetDirect is for testing - allows direct bet placement without gin
+// context. The stake is debited through the wallet pipeline, which rejects
+// it if the balance is too low.
 func (s *GameServer) PlaceBetDirect(userID string, amount float64, autoCashout *float64) error {
-	if s.currentGame == nil || s.currentGame.Status != "betting" {
-		return errors.New("game not accepting bets")
+	r := s.Room("")
+	if r == nil {
+		return errNotAcceptingBets
 	}
 
-	balance, err := s.db.GetUserBalance(userID)
-	if err != nil {
-		return err
-	}
-
-	if balance < amount {
-		return errors.New("insufficient balance")
-	}
-
-	if amount > 1000.0 {
-		return errors.New("invalid amount: maximum bet is 1000.00")
-	}
-
-	if err := s.db.UpdateBalance(userID, amount, "debit"); err != nil {
-		return err
+	if amount > r.MaxBet {
+		return fmt.Errorf("invalid amount: maximum bet is %.2f", r.MaxBet)
 	}
 
-	s.currentGame.Players[userID] = &Player{
+	player := &Player{
+		UserID:      userID,
 		BetAmount:   amount,
 		CashedOut:   false,
 		WinAmount:   0,
 		AutoCashout: autoCashout,
 	}
-
-	return nil
+	return s.placeBet(r, player)
 }
 
 func (s *GameServer) GetPlayerHistory(userID string, limit int) ([]models.GameHistory, error) {
-	return s.db.GetGameHistory(userID)
+	return s.db.GetGameHistory("")
 }
 
+// GetRecentGames returns the latest rounds of the default room.
 func (s *GameServer) GetRecentGames(limit int) ([]models.GameHistory, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if limit > len(s.gameHistory) {
-		limit = len(s.gameHistory)
+	r := s.Room("")
+	if r == nil {
+		return []models.GameHistory{}, nil
 	}
-
-	history := make([]models.GameHistory, limit)
-	copy(history, s.gameHistory[:limit])
-	return history, nil
+	return r.recentHistory(limit), nil
 }
 
+// StartGameLoop plays the rounds of every room, running the loop of the
+// default room on the calling goroutine.
 func (s *GameServer) StartGameLoop() {
-	// Initialize first game
-	s.startNewGame()
-
-	// Start the game loop
-	s.gameLoop()
+	main := s.Room("")
+	for _, r := range s.startRooms() {
+		if r != main {
+			go s.gameLoop(r)
+		}
+	}
+	if main != nil {
+		s.gameLoop(main)
+	}
 }
 
 // ConnectToServer creates a client connection to an existing server
@@ -455,12 +760,11 @@ func ConnectToServer(baseURL string, db *database.Database) *GameServer {
 	return &GameServer{
 		db:      db,
 		router:  gin.Default(),
+		clock:   clock.Real(),
 		baseURL: baseURL,
 	}
 }
 
 func (s *GameServer) GetCurrentGameState() (*GameState, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.currentGame, nil
+	return s.CurrentGame(), nil
 }
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"crash-game/internal/database"
+	"crash-game/internal/models"
+)
+
+const (
+	// settleAttempts is how often settling a round is tried before it is
+	// left for recovery at the next start.
+	settleAttempts = 5
+	// settleRetryDelay is the wait after the first failed attempt; it
+	// doubles after every further failure, up to settleMaxRetryDelay.
+	settleRetryDelay    = 500 * time.Millisecond
+	settleMaxRetryDelay = 30 * time.Second
+)
+
+// SettleBackoff returns how long to wait before retrying a settlement that
+// failed attempt times.
+func SettleBackoff(attempt int) time.Duration {
+	delay := settleRetryDelay
+	for i := 1; i < attempt && delay < settleMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > settleMaxRetryDelay {
+		delay = settleMaxRetryDelay
+	}
+	return delay
+}
+
+// PlanSettlement computes the final outcome of a crashed round: every
+// cashout, partial ones included, is paid its win and the stake still riding
+// at the crash lost.
+func PlanSettlement(round models.GameHistory) (models.Settlement, []database.Cashout) {
+	settlement := models.Settlement{
+		GameID:     round.GameID,
+		CrashPoint: round.CrashPoint,
+		CrashTime:  round.EndTime,
+		Bets:       len(round.Players),
+	}
+
+	var cashouts []database.Cashout
+	for _, bet := range round.Players {
+		settlement.Wagered += bet.BetAmount
+		lines := cashoutLines(bet, round.EndTime)
+		if len(lines) == 0 {
+			continue
+		}
+
+		for i, line := range lines {
+			cashouts = append(cashouts, database.Cashout{
+				BetID:      bet.BetID,
+				GameID:     round.GameID,
+				UserID:     bet.UserID,
+				Seq:        i + 1,
+				Stake:      line.Stake,
+				Multiplier: line.Multiplier,
+				WinAmount:  line.WinAmount,
+				At:         line.CashoutAt,
+				Final:      bet.CashedOut && i == len(lines)-1,
+			})
+			settlement.Paid += line.WinAmount
+		}
+		settlement.Winners++
+	}
+	return settlement, cashouts
+}
+
+// cashoutLines returns the cashouts of a bet. A bet recorded as cashed out
+// without its cashouts was cashed out whole, at its cashout multiplier.
+func cashoutLines(bet models.PlayerHistory, crashTime time.Time) []models.CashoutLine {
+	if len(bet.Cashouts) > 0 || !bet.CashedOut {
+		return bet.Cashouts
+	}
+
+	at := crashTime
+	if bet.CashoutAt != nil {
+		at = *bet.CashoutAt
+	}
+	return []models.CashoutLine{{
+		Stake:      bet.BetAmount,
+		Multiplier: bet.CashoutMultiplier,
+		WinAmount:  bet.WinAmount,
+		CashoutAt:  at,
+	}}
+}
+
+// settleRound credits the winners of a crashed round and records its
+// outcome, retrying with backoff. A round that still cannot be settled is
+// left unsettled for recovery at the next start and admins are notified, so
+// no payout is dropped.
+func (s *GameServer) settleRound(r *Room, g *GameState, round models.GameHistory) {
+	// Cashouts recorded through the wallet pipeline land first
+	s.wallet.Flush()
+
+	settlement, cashouts := PlanSettlement(round)
+
+	var err error
+	for attempt := 1; attempt <= settleAttempts; attempt++ {
+		settlement.Attempts = attempt
+		settlement.SettledAt = s.clock.Now()
+		err = s.db.SettleRound(&settlement, cashouts)
+		if err == nil || errors.Is(err, database.ErrRoundSettled) {
+			break
+		}
+		// Retrying cannot commit a crash point that is missing
+		if errors.Is(err, database.ErrRoundNotStarted) {
+			break
+		}
+
+		log.Printf("❌ SETTLE: Attempt %d to settle round %s failed: %v", attempt, round.GameID, err)
+		if attempt < settleAttempts {
+			s.clock.Sleep(SettleBackoff(attempt))
+		}
+	}
+
+	switch {
+	case err == nil:
+		log.Printf("✅ SETTLE: Round %s settled, %d of %d bets paid %.2f",
+			round.GameID, settlement.Winners, settlement.Bets, settlement.Paid)
+	case errors.Is(err, database.ErrRoundSettled):
+		log.Printf("SETTLE: Round %s was already settled, voided or refunded", round.GameID)
+	default:
+		message := fmt.Sprintf("Round %s in room %s could not be settled after %d attempts; %d winners are owed %.2f until it is recovered: %v",
+			round.GameID, round.RoomID, settlement.Attempts, settlement.Winners, settlement.Paid, err)
+		log.Printf("❌ SETTLE: %s", message)
+		if err := s.notificationManager.CreateNotification(&models.AdminNotification{
+			Type:     "settlement_failed",
+			Priority: "high",
+			Message:  message,
+		}); err != nil {
+			log.Printf("❌ SETTLE: Failed to notify admins: %v", err)
+		}
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := g.Settle(); err != nil {
+		log.Printf("❌ ROUND: %v", err)
+	}
+}
@@ -1,11 +1,6 @@
 package server
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
-	"strconv"
-
 	"crash-game/internal/game"
 
 	"github.com/gin-gonic/gin"
@@ -13,9 +8,11 @@ import (
 
 type VerificationData struct {
 	GameID       int64   `json:"gameId"`
+	ServerSeedID int     `json:"serverSeedId"`
 	CrashedPoint float64 `json:"crashPoint"`
 	Hash         string  `json:"hash"`
-	Seed         string  `json:"seed"`
+	ClientSeed   string  `json:"clientSeed"`
+	Distribution string  `json:"distribution"`
 }
 
 func (s *GameServer) VerifyGame(c *gin.Context) {
@@ -25,19 +22,27 @@ func (s *GameServer) VerifyGame(c *gin.Context) {
 		return
 	}
 
-	// Verify hash
-	h := hmac.New(sha256.New, []byte(game.ServerSeed))
-	h.Write([]byte(strconv.FormatInt(data.GameID, 10)))
-	expectedHash := hex.EncodeToString(h.Sum(nil))
+	terminatingHash, err := s.terminatingHash(data.ServerSeedID)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "server seed not found"})
+		return
+	}
+
+	dist, err := game.ParseDistribution(data.Distribution)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
-	if data.Hash != expectedHash {
+	// Verify hash against the published chain
+	result := game.VerifyRound(dist, data.GameID, data.Hash, data.ClientSeed, terminatingHash)
+	if !result.Valid {
 		c.JSON(400, gin.H{"error": "invalid hash"})
 		return
 	}
 
 	// Verify crash point
-	expectedCrashPoint := game.CalculateCrashPoint(data.Seed)
-	if data.CrashedPoint != expectedCrashPoint {
+	if data.CrashedPoint != result.CrashPoint {
 		c.JSON(400, gin.H{"error": "invalid crash point"})
 		return
 	}
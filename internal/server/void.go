@@ -0,0 +1,127 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"crash-game/internal/database"
+	"crash-game/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var errRoundRunning = errors.New("round still in progress")
+
+// VoidRound voids a round on behalf of an admin: every stake is refunded and
+// every win already credited is reversed. A round still being played cannot
+// be voided until it has crashed. Voiding a voided round changes nothing and
+// returns the original void with voided false.
+func (s *GameServer) VoidRound(gameID, reason string, adminID int) (void *models.RoundVoid, voided bool, err error) {
+	if room := s.playingRoom(gameID); room != nil && !room.CurrentGame().State().Finished() {
+		return nil, false, errRoundRunning
+	}
+
+	// Cashouts still queued in the wallet pipeline land before the round is
+	// voided
+	s.wallet.Flush()
+
+	void = &models.RoundVoid{
+		GameID:   gameID,
+		Reason:   reason,
+		VoidedBy: adminID,
+		VoidedAt: s.clock.Now(),
+	}
+	err = s.db.VoidRound(void)
+	if errors.Is(err, database.ErrRoundVoided) {
+		return void, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if r := s.Room(void.RoomID); r != nil {
+		r.mu.Lock()
+		for i := range r.history {
+			if r.history[i].GameID == gameID {
+				r.history[i].Status = "voided"
+			}
+		}
+		r.mu.Unlock()
+	}
+
+	log.Printf("🚫 ROUND: %s voided by admin %d, %d bets refunded %.2f and %.2f of wins reversed: %s",
+		gameID, adminID, void.Bets, void.Refunded, void.Reversed, reason)
+
+	// The room only learns that the round was voided; every affected player
+	// is told what happened to their own bets, wherever they are connected
+	s.broadcastRoomMessage(void.RoomID, WSMessage{Type: "round_voided", Payload: gin.H{
+		"gameId":   void.GameID,
+		"roomId":   void.RoomID,
+		"reason":   void.Reason,
+		"voidedAt": void.VoidedAt,
+	}})
+	refunds := make(map[string][]models.BetRefund)
+	for _, refund := range void.Refunds {
+		refunds[refund.UserID] = append(refunds[refund.UserID], refund)
+	}
+	for userID, bets := range refunds {
+		s.sendToUser(userID, WSMessage{Type: "bets_voided", Payload: gin.H{
+			"gameId":   void.GameID,
+			"roomId":   void.RoomID,
+			"reason":   void.Reason,
+			"voidedAt": void.VoidedAt,
+			"refunds":  bets,
+		}})
+	}
+
+	if err := s.notificationManager.CreateNotification(&models.AdminNotification{
+		Type:     "round_voided",
+		Priority: "high",
+		Message: fmt.Sprintf("Round %s was voided by admin %d: %d bets refunded %.2f, %.2f of wins reversed",
+			gameID, adminID, void.Bets, void.Refunded, void.Reversed),
+	}); err != nil {
+		log.Printf("❌ ROUND: Failed to notify admins: %v", err)
+	}
+	return void, true, nil
+}
+
+func (s *GameServer) HandleVoidRound(c *gin.Context) {
+	gameID := c.Param("id")
+	adminID := c.GetInt("adminId")
+	if _, err := uuid.Parse(gameID); err != nil {
+		c.JSON(404, gin.H{"error": "round not found"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "reason is required"})
+		return
+	}
+
+	void, voided, err := s.VoidRound(gameID, req.Reason, adminID)
+	switch {
+	case errors.Is(err, database.ErrRoundNotFound):
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errRoundRunning), errors.Is(err, database.ErrRoundRefunded):
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("❌ ROUND: Failed to void %s: %v", gameID, err)
+		c.JSON(500, gin.H{"error": "failed to void round"})
+		return
+	}
+
+	if voided {
+		if err := s.db.LogAdminAction(adminID, "round_void", "game", gameID, void); err != nil {
+			log.Printf("❌ ROUND: Failed to log admin action: %v", err)
+		}
+	}
+
+	c.JSON(200, void)
+}
@@ -1,8 +1,15 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
+
+	"crash-game/internal/auth"
+	"crash-game/internal/database"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -17,6 +24,14 @@ var upgrader = websocket.Upgrader{
 type Client struct {
 	conn *websocket.Conn
 	send chan WSMessage
+	// room limits round events to one room; empty receives every room
+	room string
+	// userID is the player the client authenticated as; empty for
+	// spectators, who cannot send commands
+	userID string
+
+	mu     sync.Mutex
+	closed bool
 }
 
 type WSMessage struct {
@@ -24,7 +39,35 @@ type WSMessage struct {
 	Payload interface{} `json:"payload"`
 }
 
+// wsCommand is a message sent by a client; each command decodes its own
+// payload.
+type wsCommand struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
 func (s *GameServer) handleWebSocket(c *gin.Context) {
+	roomID := c.Query("roomId")
+	if roomID != "" && s.Room(roomID) == nil {
+		c.JSON(404, gin.H{"error": "room not found"})
+		return
+	}
+
+	// Players authenticate with their token to send commands
+	var userID string
+	token := c.Query("token")
+	if token == "" {
+		token = strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+	}
+	if token != "" {
+		claims, err := auth.ValidateToken(token)
+		if err != nil {
+			c.JSON(401, gin.H{"error": "invalid token"})
+			return
+		}
+		userID = claims.UserID
+	}
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade failed: %v", err)
@@ -32,8 +75,10 @@ func (s *GameServer) handleWebSocket(c *gin.Context) {
 	}
 
 	client := &Client{
-		conn: conn,
-		send: make(chan WSMessage, 256),
+		conn:   conn,
+		send:   make(chan WSMessage, 256),
+		room:   roomID,
+		userID: userID,
 	}
 
 	s.clients.Store(client, true)
@@ -62,24 +107,116 @@ func (client *Client) readPump(s *GameServer) {
 	}()
 
 	for {
-		var message WSMessage
-		err := client.conn.ReadJSON(&message)
+		var command wsCommand
+		err := client.conn.ReadJSON(&command)
 		if err != nil {
 			break
 		}
 
-		// Handle incoming messages if needed
+		s.handleCommand(client, command)
+	}
+}
+
+// deliver queues a message for the client. A client that does not keep up is
+// closed; deliver reports whether the client is still open.
+func (client *Client) deliver(message WSMessage) bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if client.closed {
+		return false
+	}
+	select {
+	case client.send <- message:
+		return true
+	default:
+		client.closed = true
+		close(client.send)
+		return false
+	}
+}
+
+// sendToUser sends a message to every client the user is connected with.
+func (s *GameServer) sendToUser(userID string, message WSMessage) {
+	s.clients.Range(func(key, _ interface{}) bool {
+		if client, ok := key.(*Client); ok && client.userID == userID {
+			if !client.deliver(message) {
+				s.clients.Delete(client)
+			}
+		}
+		return true
+	})
+}
+
+// handleCommand runs a command sent by a client and answers on the same
+// connection. Unknown commands are ignored.
+func (s *GameServer) handleCommand(client *Client, command wsCommand) {
+	switch command.Type {
+	case "cancel_bet":
+		s.wsCancelBet(client, command.Payload)
+	}
+}
+
+// wsCancelBet cancels a bet of the client's player, in the room given or the
+// room the client follows.
+func (s *GameServer) wsCancelBet(client *Client, payload json.RawMessage) {
+	if client.userID == "" {
+		client.deliver(wsError("cancel_bet", "authentication required"))
+		return
+	}
+
+	var req struct {
+		RoomID string `json:"roomId"`
+		Slot   int    `json:"slot"`
+	}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &req); err != nil {
+			client.deliver(wsError("cancel_bet", "invalid request"))
+			return
+		}
+	}
+	if req.RoomID == "" {
+		req.RoomID = client.room
+	}
+	r := s.Room(req.RoomID)
+	if r == nil {
+		client.deliver(wsError("cancel_bet", "room not found"))
+		return
+	}
+
+	player, queued, err := s.cancelBet(r, client.userID, req.Slot)
+	switch {
+	case errors.Is(err, errBettingClosed), errors.Is(err, database.ErrBetNotCancellable), errors.Is(err, errNoBet):
+		client.deliver(wsError("cancel_bet", err.Error()))
+		return
+	case err != nil:
+		log.Printf("❌ BET: Failed to cancel bet: %v", err)
+		client.deliver(wsError("cancel_bet", "failed to cancel bet"))
+		return
 	}
+
+	client.deliver(WSMessage{Type: "bet_cancelled", Payload: cancelledBet(r, player, queued)})
+}
+
+// wsError answers a command that failed.
+func wsError(command, message string) WSMessage {
+	return WSMessage{Type: "error", Payload: gin.H{"command": command, "error": message}}
 }
 
 func (s *GameServer) broadcastMessage(message WSMessage) {
+	s.broadcastRoomMessage("", message)
+}
+
+// broadcastRoomMessage sends a message to the clients following a room and
+// to those following every room. An empty roomID reaches all clients.
+func (s *GameServer) broadcastRoomMessage(roomID string, message WSMessage) {
 	s.clients.Range(func(key, _ interface{}) bool {
 		if client, ok := key.(*Client); ok {
-			select {
-			case client.send <- message:
-			default:
+			if roomID != "" && client.room != "" && client.room != roomID {
+				return true
+			}
+			if !client.deliver(message) {
 				s.clients.Delete(client)
-				close(client.send)
 			}
 		}
 		return true
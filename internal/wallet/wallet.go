@@ -0,0 +1,126 @@
+package wallet
+
+import (
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"crash-game/internal/database"
+	"crash-game/internal/models"
+)
+
+// Store is where the pipeline applies balance changes; the database in
+// production.
+type Store interface {
+	PlaceBet(gameID string, serverSeedID int, bet *models.PlayerHistory) error
+	CancelBet(bet models.PlayerHistory, at time.Time) error
+	RecordCashout(cashout database.Cashout) error
+}
+
+// Pipeline applies balance changes away from the round locks, so a slow
+// database holds up no bet, cashout or game loop tick. Changes of one user
+// are applied in the order they were submitted; changes of different users
+// are applied concurrently.
+type Pipeline struct {
+	store  Store
+	queues []*queue
+}
+
+// New starts a pipeline applying changes to store on the given number of
+// workers. A user's changes always go to the same worker.
+func New(store Store, workers int) *Pipeline {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &Pipeline{store: store, queues: make([]*queue, workers)}
+	for i := range p.queues {
+		p.queues[i] = newQueue()
+		go p.queues[i].run()
+	}
+	return p
+}
+
+// PlaceBet debits the stake of a bet and records it, waiting for the result.
+// The caller must not hold a round lock.
+func (p *Pipeline) PlaceBet(gameID string, serverSeedID int, bet *models.PlayerHistory) error {
+	done := make(chan error, 1)
+	p.submit(bet.UserID, func() {
+		done <- p.store.PlaceBet(gameID, serverSeedID, bet)
+	})
+	return <-done
+}
+
+// CancelBet refunds the stake of a bet and marks it cancelled, waiting for the
+// result. The caller must not hold a round lock.
+func (p *Pipeline) CancelBet(bet models.PlayerHistory, at time.Time) error {
+	done := make(chan error, 1)
+	p.submit(bet.UserID, func() {
+		done <- p.store.CancelBet(bet, at)
+	})
+	return <-done
+}
+
+// RecordCashout queues a cashout to be recorded on its bet and returns at
+// once. The round's settlement writes the cashout again, so a failure here
+// only costs durability until then.
+func (p *Pipeline) RecordCashout(cashout database.Cashout) {
+	p.submit(cashout.UserID, func() {
+		if err := p.store.RecordCashout(cashout); err != nil {
+			log.Printf("❌ WALLET: Failed to record cashout of %s: %v", cashout.UserID, err)
+		}
+	})
+}
+
+// Flush waits until every change submitted before it has been applied.
+func (p *Pipeline) Flush() {
+	var wg sync.WaitGroup
+	wg.Add(len(p.queues))
+	for _, q := range p.queues {
+		q.push(wg.Done)
+	}
+	wg.Wait()
+}
+
+func (p *Pipeline) submit(userID string, change func()) {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	p.queues[h.Sum32()%uint32(len(p.queues))].push(change)
+}
+
+// queue is an unbounded FIFO of changes, so submitting never blocks the
+// caller, drained by a single worker.
+type queue struct {
+	mu      sync.Mutex
+	ready   *sync.Cond
+	changes []func()
+}
+
+func newQueue() *queue {
+	q := &queue{}
+	q.ready = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *queue) push(change func()) {
+	q.mu.Lock()
+	q.changes = append(q.changes, change)
+	q.mu.Unlock()
+	q.ready.Signal()
+}
+
+func (q *queue) run() {
+	for {
+		q.mu.Lock()
+		for len(q.changes) == 0 {
+			q.ready.Wait()
+		}
+		change := q.changes[0]
+		q.changes[0] = nil
+		q.changes = q.changes[1:]
+		q.mu.Unlock()
+
+		change()
+	}
+}
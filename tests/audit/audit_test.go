@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"crash-game/internal/audit"
+	"crash-game/internal/game"
+	"errors"
+	"math"
+	"testing"
+)
+
+var testChain = game.NewSeedChain("audit-server-seed", 20000)
+
+func crashPoints(dist game.CrashDistribution, rounds int64) []float64 {
+	points := make([]float64, 0, rounds)
+	for round := int64(1); round <= rounds; round++ {
+		points = append(points, testChain.Result(dist, round, "").CrashPoint)
+	}
+	return points
+}
+
+func TestAuditAcceptsChainCrashPoints(t *testing.T) {
+	dist := game.DefaultDistribution()
+	report, err := audit.Analyze(crashPoints(dist, 20000), dist, audit.Options{})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	if !report.ChiSquare.Pass || !report.KS.Pass {
+		t.Errorf("Crash points of the chain should fit their distribution: chi-square p=%f, KS p=%f",
+			report.ChiSquare.PValue, report.KS.PValue)
+	}
+	if math.Abs(report.ExpectedRTP-(1-game.DefaultHouseEdge)) > 1e-9 {
+		t.Errorf("Expected RTP %f, want %f", report.ExpectedRTP, 1-game.DefaultHouseEdge)
+	}
+
+	total := 0
+	for _, b := range report.Histogram {
+		total += b.Observed
+	}
+	if total != report.Rounds {
+		t.Errorf("Histogram holds %d rounds, want %d", total, report.Rounds)
+	}
+}
+
+func TestAuditDetectsWrongHouseEdge(t *testing.T) {
+	played := game.HouseEdgeDistribution{HouseEdge: 0.10}
+	report, err := audit.Analyze(crashPoints(played, 20000), game.DefaultDistribution(), audit.Options{})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	if report.Pass {
+		t.Errorf("A 10%% house edge should not pass as 2%%: chi-square p=%f, KS p=%f",
+			report.ChiSquare.PValue, report.KS.PValue)
+	}
+	if report.EmpiricalRTP > report.ExpectedRTP-0.04 {
+		t.Errorf("Empirical RTP %f should be well below expected %f", report.EmpiricalRTP, report.ExpectedRTP)
+	}
+}
+
+func TestAuditDetectsPointsAboveCap(t *testing.T) {
+	capped := game.CappedDistribution{Base: game.DefaultDistribution(), Cap: 10}
+	report, err := audit.Analyze(crashPoints(game.DefaultDistribution(), 5000), capped, audit.Options{})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if report.ChiSquare.Pass || report.KS.Pass {
+		t.Errorf("Crash points above the cap should fail both tests: chi-square p=%f, KS p=%f",
+			report.ChiSquare.PValue, report.KS.PValue)
+	}
+}
+
+func TestAuditRequiresRounds(t *testing.T) {
+	if _, err := audit.Analyze(nil, game.DefaultDistribution(), audit.Options{}); !errors.Is(err, audit.ErrNoRounds) {
+		t.Errorf("Expected ErrNoRounds, got %v", err)
+	}
+}
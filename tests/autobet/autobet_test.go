@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"crash-game/internal/autobet"
+	"errors"
+	"testing"
+)
+
+func TestStrategiesSetTheNextStake(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  autobet.Config
+		results []bool // won or lost, round by round
+		want    []float64
+	}{
+		{"flat", autobet.Config{Strategy: autobet.Flat}, []bool{false, true, false}, []float64{10, 10, 10}},
+		{"martingale", autobet.Config{Strategy: autobet.Martingale}, []bool{false, false, true}, []float64{20, 40, 10}},
+		{"anti-martingale", autobet.Config{Strategy: autobet.AntiMartingale}, []bool{true, true, false}, []float64{20, 40, 10}},
+		{"custom", autobet.Config{Strategy: autobet.Custom, OnLossPercent: 50}, []bool{false, false, true}, []float64{15, 22.5, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.BaseAmount = 10
+			tt.config.AutoCashout = 2
+			session, err := autobet.NewSession(tt.config)
+			if err != nil {
+				t.Fatalf("NewSession failed: %v", err)
+			}
+
+			for i, won := range tt.results {
+				bet, ok := session.NextBet(1000)
+				if !ok {
+					t.Fatalf("Round %d: session stopped: %s", i+1, session.Progress().StopReason)
+				}
+				win := 0.0
+				if won {
+					win = bet.Amount * 2
+				}
+				session.Record(bet.Amount, win, 2)
+
+				if next := session.Progress().NextAmount; next != tt.want[i] {
+					t.Errorf("Round %d: next stake = %.2f, want %.2f", i+1, next, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBreakEvenIsNotALoss(t *testing.T) {
+	session, err := autobet.NewSession(autobet.Config{Strategy: autobet.Martingale, BaseAmount: 10, AutoCashout: 2})
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+
+	// Lose once, then get the doubled stake back from a cashout forced at 1.00x
+	session.Record(10, 0, 1.5)
+	bet, ok := session.NextBet(1000)
+	if !ok {
+		t.Fatalf("Session stopped: %s", session.Progress().StopReason)
+	}
+	session.Record(bet.Amount, bet.Amount*1.00, 3)
+
+	p := session.Progress()
+	if p.Wins != 1 || p.Losses != 1 {
+		t.Errorf("Wins/losses = %d/%d, want the break-even counted as a win", p.Wins, p.Losses)
+	}
+	if p.NextAmount != 10 {
+		t.Errorf("Next stake = %.2f, want back to the base of 10 instead of doubling again", p.NextAmount)
+	}
+}
+
+func TestStopConditions(t *testing.T) {
+	base := autobet.Config{Strategy: autobet.Flat, BaseAmount: 10, AutoCashout: 2}
+
+	tests := []struct {
+		name   string
+		stop   autobet.StopConditions
+		won    bool
+		rounds int
+		want   string
+	}{
+		{"rounds", autobet.StopConditions{Rounds: 3}, true, 3, autobet.StopRounds},
+		{"profit target", autobet.StopConditions{ProfitTarget: 25}, true, 3, autobet.StopProfitTarget},
+		{"loss limit", autobet.StopConditions{LossLimit: 20}, false, 2, autobet.StopLossLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := base
+			config.Stop = tt.stop
+			session, err := autobet.NewSession(config)
+			if err != nil {
+				t.Fatalf("NewSession failed: %v", err)
+			}
+
+			rounds := 0
+			for !session.Stopped() && rounds < 10 {
+				bet, ok := session.NextBet(1000)
+				if !ok {
+					break
+				}
+				win := 0.0
+				if tt.won {
+					win = bet.Amount * 2
+				}
+				session.Record(bet.Amount, win, 2)
+				rounds++
+			}
+
+			if got := session.Progress().StopReason; got != tt.want {
+				t.Errorf("Stop reason = %q, want %q", got, tt.want)
+			}
+			if rounds != tt.rounds {
+				t.Errorf("Stopped after %d rounds, want %d", rounds, tt.rounds)
+			}
+		})
+	}
+}
+
+func TestBalanceFloorStopsBeforeBetting(t *testing.T) {
+	session, err := autobet.NewSession(autobet.Config{
+		Strategy: autobet.Flat, BaseAmount: 10, AutoCashout: 2,
+		Stop: autobet.StopConditions{BalanceFloor: 50},
+	})
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+
+	if _, ok := session.NextBet(60); !ok {
+		t.Fatal("Expected a bet leaving the balance at the floor")
+	}
+	if _, ok := session.NextBet(59); ok {
+		t.Fatal("Expected no bet taking the balance below the floor")
+	}
+	if got := session.Progress().StopReason; got != autobet.StopBalanceFloor {
+		t.Errorf("Stop reason = %q, want %q", got, autobet.StopBalanceFloor)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := autobet.Config{Strategy: autobet.Martingale, BaseAmount: 1, AutoCashout: 2}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Valid config rejected: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*autobet.Config)
+	}{
+		{"unknown strategy", func(c *autobet.Config) { c.Strategy = "fibonacci" }},
+		{"no base amount", func(c *autobet.Config) { c.BaseAmount = 0 }},
+		{"auto-cashout at 1", func(c *autobet.Config) { c.AutoCashout = 1 }},
+		{"negative increase", func(c *autobet.Config) { c.OnLossPercent = -10 }},
+		{"negative loss limit", func(c *autobet.Config) { c.Stop.LossLimit = -1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.modify(&config)
+			if err := config.Validate(); !errors.Is(err, autobet.ErrInvalidConfig) {
+				t.Errorf("Validate() = %v, want ErrInvalidConfig", err)
+			}
+		})
+	}
+}
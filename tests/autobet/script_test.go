@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"crash-game/internal/autobet"
+	"crash-game/internal/autobet/script"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func runScript(t *testing.T, source string, in script.Input) script.Output {
+	t.Helper()
+	program, err := script.Compile(source, script.DefaultLimits())
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	out, err := program.Run(in)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	return out
+}
+
+func TestScriptSetsBetAndCashout(t *testing.T) {
+	source := `
+# martingale with a cap, cashing out lower after a low crash
+if payout(1) > 0 { bet = base } else { bet = min(stake(1) * 2, 100) }
+cashout = crash(1) < 1.5 ? 1.5 : 2
+stop = balance < 50
+`
+	in := script.Input{
+		Balance: 500, Base: 10, Bet: 10, Cashout: 3,
+		History: []script.Round{{Stake: 80, CrashPoint: 1.2}},
+	}
+	out := runScript(t, source, in)
+	if out.Bet != 100 || out.Cashout != 1.5 || out.Stop {
+		t.Errorf("Output = %+v, want bet 100 at 1.5x", out)
+	}
+
+	// Without history every round looks lost at a crash point of 0
+	in.History = nil
+	in.Balance = 40
+	out = runScript(t, source, in)
+	if out.Bet != 0 || out.Cashout != 1.5 || !out.Stop {
+		t.Errorf("Output = %+v, want a stop", out)
+	}
+}
+
+func TestScriptKeepsDefaultsItDoesNotSet(t *testing.T) {
+	out := runScript(t, "x = 2; bet = bet * x", script.Input{Bet: 5, Cashout: 3})
+	if out.Bet != 10 || out.Cashout != 3 || out.Stop {
+		t.Errorf("Output = %+v, want bet 10 at the default 3x", out)
+	}
+}
+
+func TestScriptCentsRoundsToCents(t *testing.T) {
+	out := runScript(t, "bet = cents(base / 3); cashout = cents(1.006 + 1)", script.Input{Base: 10, Cashout: 2})
+	if out.Bet != 3.33 || out.Cashout != 2.01 {
+		t.Errorf("Output = %+v, want bet 3.33 at 2.01x", out)
+	}
+}
+
+func TestScriptCompileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"syntax", "bet = (1 + 2", `expected ")"`},
+		{"unknown variable", "bet = total", "unknown variable total"},
+		{"unknown function", "bet = exec(1)", "unknown function exec"},
+		{"round is cents", "bet = round(base)", "unknown function round"},
+		{"input", "balance = 1000000", "cannot assign to balance"},
+		{"arity", "bet = clamp(1, 2)", "wrong number of arguments"},
+		{"two statements on a line", "bet = 1 cashout = 2", "unexpected"},
+		{"unknown character", "bet = \"10\"", "unexpected character"},
+		{"too long", "bet = " + strings.Repeat("1+", 3000) + "1", "longer than"},
+		{"too deep", "bet = " + strings.Repeat("(", 100) + "1" + strings.Repeat(")", 100), "nested too deeply"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := script.Compile(tt.source, script.DefaultLimits())
+			if !errors.Is(err, script.ErrInvalidScript) {
+				t.Fatalf("Compile() = %v, want ErrInvalidScript", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile() = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptLimits(t *testing.T) {
+	limits := script.DefaultLimits()
+	limits.MaxNodes = 20
+	if _, err := script.Compile("bet = "+strings.Repeat("1+", 20)+"1", limits); !errors.Is(err, script.ErrInvalidScript) {
+		t.Errorf("Compile() of a large script = %v, want ErrInvalidScript", err)
+	}
+
+	limits = script.DefaultLimits()
+	limits.MaxVars = 2
+	if _, err := script.Compile("a = 1; b = 2; c = 3", limits); !errors.Is(err, script.ErrInvalidScript) {
+		t.Errorf("Compile() with too many variables = %v, want ErrInvalidScript", err)
+	}
+
+	limits = script.DefaultLimits()
+	limits.MaxSteps = 10
+	program, err := script.Compile("bet = "+strings.Repeat("1+", 20)+"1", limits)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if _, err := program.Run(script.Input{}); !errors.Is(err, script.ErrStepLimit) {
+		t.Errorf("Run() = %v, want ErrStepLimit", err)
+	}
+}
+
+func TestScriptRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"division by zero", "bet = base / rounds"},
+		{"variable not set", "if rounds > 0 { x = 1 }\nbet = x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := script.Compile(tt.source, script.DefaultLimits())
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if _, err := program.Run(script.Input{Base: 10}); !errors.Is(err, script.ErrRuntime) {
+				t.Errorf("Run() = %v, want ErrRuntime", err)
+			}
+		})
+	}
+}
+
+func TestScriptStrategy(t *testing.T) {
+	config := autobet.Config{
+		Strategy: autobet.Script, BaseAmount: 10, AutoCashout: 2,
+		Script: "bet = rounds < 2 ? base * (rounds + 1) : 0\nstop = rounds >= 2",
+	}
+	session, err := autobet.NewSession(config)
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+
+	for round, want := range []float64{10, 20} {
+		bet, ok := session.NextBet(1000)
+		if !ok {
+			t.Fatalf("Round %d: session stopped: %s", round+1, session.Progress().StopReason)
+		}
+		if bet.Amount != want || bet.AutoCashout != 2 {
+			t.Errorf("Round %d: bet = %+v, want %.2f at 2x", round+1, bet, want)
+		}
+		session.Record(bet.Amount, 0, 1.5)
+	}
+
+	if _, ok := session.NextBet(1000); ok {
+		t.Fatal("Expected the script to stop the session")
+	}
+	if got := session.Progress().StopReason; got != autobet.StopByScript {
+		t.Errorf("Stop reason = %q, want %q", got, autobet.StopByScript)
+	}
+}
+
+func TestScriptStrategyValidatedOnUpload(t *testing.T) {
+	config := autobet.Config{Strategy: autobet.Script, BaseAmount: 10, AutoCashout: 2}
+
+	for _, source := range []string{"", "bet = ", "bet = base / (rounds - 1)"} {
+		config.Script = source
+		if _, err := autobet.NewSession(config); !errors.Is(err, autobet.ErrInvalidConfig) {
+			t.Errorf("NewSession() with script %q = %v, want ErrInvalidConfig", source, err)
+		}
+	}
+}
+
+func TestFailingScriptStopsSession(t *testing.T) {
+	session, err := autobet.NewSession(autobet.Config{
+		Strategy: autobet.Script, BaseAmount: 10, AutoCashout: 2,
+		Script: "bet = base / (wins - 1)",
+	})
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	session.Record(10, 20, 2.5)
+
+	if _, ok := session.NextBet(1000); ok {
+		t.Fatal("Expected no bet from a failing script")
+	}
+	if got := session.Progress().StopReason; got != autobet.StopScriptError {
+		t.Errorf("Stop reason = %q, want %q", got, autobet.StopScriptError)
+	}
+	if !errors.Is(session.Err(), script.ErrRuntime) {
+		t.Errorf("Err() = %v, want ErrRuntime", session.Err())
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"crash-game/internal/clock"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestManualSleepWakesOnAdvance(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	woke := make(chan time.Time)
+
+	go func() {
+		clk.Sleep(5 * time.Second)
+		woke <- clk.Now()
+	}()
+
+	clk.BlockUntil(1)
+	clk.Advance(4 * time.Second)
+	select {
+	case <-woke:
+		t.Fatal("Sleep returned before its deadline")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clk.Advance(time.Second)
+	if now := <-woke; !now.Equal(epoch.Add(5 * time.Second)) {
+		t.Errorf("Woke at %v, want %v", now, epoch.Add(5*time.Second))
+	}
+}
+
+func TestManualFiresInDeadlineOrder(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	late := clk.After(2 * time.Second)
+	early := clk.After(time.Second)
+
+	clk.Advance(3 * time.Second)
+
+	if at := <-early; !at.Equal(epoch.Add(3 * time.Second)) {
+		t.Errorf("Early timer fired at %v", at)
+	}
+	if at := <-late; !at.Equal(epoch.Add(3 * time.Second)) {
+		t.Errorf("Late timer fired at %v", at)
+	}
+}
+
+func TestManualAfterFuncStop(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	fired := make(chan struct{}, 2)
+
+	stopped := clk.AfterFunc(time.Second, func() { fired <- struct{}{} })
+	clk.AfterFunc(time.Second, func() { fired <- struct{}{} })
+
+	if !stopped.Stop() {
+		t.Error("Stop of a pending timer should report true")
+	}
+	clk.Advance(time.Second)
+
+	<-fired
+	select {
+	case <-fired:
+		t.Error("Stopped timer fired")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if stopped.Stop() {
+		t.Error("Stop of a removed timer should report false")
+	}
+}
+
+func TestManualSince(t *testing.T) {
+	clk := clock.NewManual(epoch)
+	clk.Advance(90 * time.Second)
+
+	if d := clk.Since(epoch); d != 90*time.Second {
+		t.Errorf("Since = %v, want 90s", d)
+	}
+}
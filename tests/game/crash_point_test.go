@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"crash-game/internal/audit"
 	"crash-game/internal/game"
 	"testing"
 )
@@ -20,7 +21,7 @@ func TestCrashPointCalculation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := game.GenerateNextGame(tt.gameID)
+			result := testChain.Result(game.DefaultDistribution(), tt.gameID, "")
 			if result.CrashPoint < 1.0 {
 				t.Errorf("Crash point %f is less than minimum 1.0", result.CrashPoint)
 			}
@@ -29,39 +30,31 @@ func TestCrashPointCalculation(t *testing.T) {
 }
 
 func TestCrashPointDistribution(t *testing.T) {
-	var sum float64
-	iterations := 1000
-
-	for i := 0; i < iterations; i++ {
-		result := game.GenerateNextGame(int64(i))
-		sum += result.CrashPoint
-
-		if result.CrashPoint < 1.0 {
-			t.Errorf("Invalid crash point %f (less than 1.0)", result.CrashPoint)
-		}
-		if result.CrashPoint > 10.0 {
-			t.Errorf("Invalid crash point %f (greater than 10.0)", result.CrashPoint)
-		}
+	dist := game.DefaultDistribution()
+	points := make([]float64, 0, 10000)
+	for i := 1; i <= 10000; i++ {
+		points = append(points, testChain.Result(dist, int64(i), "").CrashPoint)
 	}
 
-	average := sum / float64(iterations)
-	expectedAverage := 5.5 // Average of uniform distribution between 1 and 10
-	tolerance := 0.5
-
-	if average < expectedAverage-tolerance || average > expectedAverage+tolerance {
-		t.Errorf("Unexpected average crash point: %f (expected around %f)", average, expectedAverage)
+	report, err := audit.Analyze(points, dist, audit.Options{})
+	if err != nil {
+		t.Fatalf("Audit failed: %v", err)
+	}
+	if !report.Pass {
+		t.Errorf("Crash points do not fit the distribution: chi-square p=%f, KS p=%f",
+			report.ChiSquare.PValue, report.KS.PValue)
 	}
 }
 
 func TestGameHashGeneration(t *testing.T) {
-	game1 := game.GenerateNextGame(1)
-	game2 := game.GenerateNextGame(1)
+	game1 := testChain.Result(game.DefaultDistribution(), 1, "")
+	game2 := testChain.Result(game.DefaultDistribution(), 1, "")
 
 	if game1.Hash != game2.Hash {
 		t.Error("Same game ID should produce same hash")
 	}
 
-	game3 := game.GenerateNextGame(2)
+	game3 := testChain.Result(game.DefaultDistribution(), 2, "")
 	if game1.Hash == game3.Hash {
 		t.Error("Different game IDs should produce different hashes")
 	}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"crash-game/internal/game"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExponentialCurve(t *testing.T) {
+	curve := game.DefaultCurve()
+	for _, elapsed := range []time.Duration{0, time.Second, 10 * time.Second, 45 * time.Second} {
+		want := math.Exp(0.1 * elapsed.Seconds())
+		if got := curve.Multiplier(elapsed); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Multiplier(%v) = %f, want %f", elapsed, got, want)
+		}
+	}
+}
+
+func TestCurveTimeForInvertsMultiplier(t *testing.T) {
+	curves := []game.GrowthCurve{
+		game.ExponentialCurve{Rate: 0.07},
+		game.PiecewiseCurve{Segments: []game.CurveSegment{
+			{Rate: 0.05, Until: 10 * time.Second},
+			{Rate: 0.2, Until: 20 * time.Second},
+			{Rate: 0.1},
+		}},
+	}
+
+	for _, curve := range curves {
+		for _, multiplier := range []float64{1.01, 1.5, 2, 4.5, 10, 100} {
+			elapsed := curve.TimeFor(multiplier)
+			if got := curve.Multiplier(elapsed); math.Abs(got-multiplier)/multiplier > 1e-6 {
+				t.Errorf("%s: Multiplier(TimeFor(%.2f)) = %f", curve.Spec(), multiplier, got)
+			}
+		}
+		if got := curve.TimeFor(1); got != 0 {
+			t.Errorf("%s: TimeFor(1) = %v, want 0", curve.Spec(), got)
+		}
+	}
+}
+
+func TestPiecewiseCurveIsContinuous(t *testing.T) {
+	curve := game.PiecewiseCurve{Segments: []game.CurveSegment{
+		{Rate: 0.05, Until: 10 * time.Second},
+		{Rate: 0.2},
+	}}
+
+	before := curve.Multiplier(10*time.Second - time.Millisecond)
+	after := curve.Multiplier(10*time.Second + time.Millisecond)
+	if after <= before || after-before > 0.001 {
+		t.Errorf("Curve jumps at segment boundary: %f -> %f", before, after)
+	}
+	if want := math.Exp(0.5); math.Abs(curve.Multiplier(10*time.Second)-want) > 1e-9 {
+		t.Errorf("Multiplier at boundary = %f, want %f", curve.Multiplier(10*time.Second), want)
+	}
+}
+
+func TestParseCurve(t *testing.T) {
+	for _, spec := range []string{"exponential:0.1", "exponential:0.25", "piecewise:0.05@10s,0.1", "piecewise:0.05@5s,0.2@15s,0.1"} {
+		curve, err := game.ParseCurve(spec)
+		if err != nil {
+			t.Fatalf("ParseCurve(%q) failed: %v", spec, err)
+		}
+		if curve.Spec() != spec {
+			t.Errorf("ParseCurve(%q).Spec() = %q", spec, curve.Spec())
+		}
+	}
+
+	if curve, err := game.ParseCurve(""); err != nil || curve.Spec() != game.DefaultCurveSpec {
+		t.Errorf("Empty spec should select the default curve, got %v, %v", curve, err)
+	}
+
+	for _, spec := range []string{"linear:1", "exponential:0", "exponential:-1", "exponential:NaN", "exponential:+Inf", "exponential:1e-300", "piecewise:NaN@10s,0.1", "piecewise:0.1@10s", "piecewise:0.1@10s,0.2@5s,0.1", "piecewise:"} {
+		if _, err := game.ParseCurve(spec); !errors.Is(err, game.ErrInvalidCurve) {
+			t.Errorf("ParseCurve(%q) error = %v, want ErrInvalidCurve", spec, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"crash-game/internal/game"
+	"errors"
+	"testing"
+)
+
+func TestCappedDistribution(t *testing.T) {
+	dist := game.CappedDistribution{Base: game.DefaultDistribution(), Cap: 10}
+
+	capped := 0
+	for i := 1; i <= 1000; i++ {
+		result := testChain.Result(dist, int64(i), "")
+		if result.CrashPoint > 10 {
+			t.Fatalf("Round %d crashed at %.2f above the cap", i, result.CrashPoint)
+		}
+		if result.CrashPoint == 10 {
+			capped++
+		}
+	}
+	if capped == 0 {
+		t.Error("Expected some rounds to be capped at 10x")
+	}
+}
+
+func TestFixedDistribution(t *testing.T) {
+	dist := game.FixedDistribution{Point: 2.5}
+	for i := 1; i <= 10; i++ {
+		if cp := testChain.Result(dist, int64(i), "").CrashPoint; cp != 2.5 {
+			t.Errorf("Round %d crashed at %.2f, want 2.50", i, cp)
+		}
+	}
+}
+
+func TestParseDistributionRoundTrip(t *testing.T) {
+	specs := []string{
+		game.DefaultDistributionSpec,
+		"house_edge:0.01",
+		"capped:1000:house_edge:0.03",
+		"fixed:2.5",
+	}
+
+	for _, spec := range specs {
+		dist, err := game.ParseDistribution(spec)
+		if err != nil {
+			t.Fatalf("ParseDistribution(%q): %v", spec, err)
+		}
+		if dist.Spec() != spec {
+			t.Errorf("ParseDistribution(%q).Spec() = %q", spec, dist.Spec())
+		}
+	}
+
+	dist, err := game.ParseDistribution("")
+	if err != nil || dist.Spec() != game.DefaultDistributionSpec {
+		t.Errorf("Empty spec should select the default distribution, got %v, %v", dist, err)
+	}
+}
+
+func TestParseDistributionRejectsInvalidSpecs(t *testing.T) {
+	specs := []string{
+		"unknown:1",
+		"house_edge:abc",
+		"house_edge:1",
+		"house_edge:-0.1",
+		"capped:0.5:house_edge:0.02",
+		"capped:10:fixed:0",
+		"fixed:0.5",
+		"fixed:inf",
+		"fixed:NaN",
+		"house_edge:NaN",
+		"capped:NaN:house_edge:0.02",
+		"capped:+Inf:house_edge:0.02",
+	}
+
+	for _, spec := range specs {
+		if _, err := game.ParseDistribution(spec); !errors.Is(err, game.ErrInvalidDistribution) {
+			t.Errorf("ParseDistribution(%q) error = %v, want ErrInvalidDistribution", spec, err)
+		}
+	}
+}
+
+func TestFixedDistributionIsNotPlayable(t *testing.T) {
+	tests := []struct {
+		spec     string
+		playable bool
+	}{
+		{game.DefaultDistributionSpec, true},
+		{"capped:1000:house_edge:0.02", true},
+		{"fixed:2.5", false},
+		{"capped:10:fixed:2.5", false},
+	}
+
+	for _, tt := range tests {
+		dist, err := game.ParseDistribution(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseDistribution(%q): %v", tt.spec, err)
+		}
+		if got := game.PlayableDistribution(dist); got != tt.playable {
+			t.Errorf("PlayableDistribution(%q) = %v, want %v", tt.spec, got, tt.playable)
+		}
+	}
+}
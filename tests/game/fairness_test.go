@@ -8,47 +8,45 @@ import (
 func TestGameVerification(t *testing.T) {
 	// Generate a game result
 	gameID := int64(1)
-	result := game.GenerateNextGame(gameID)
+	result := testChain.Result(game.DefaultDistribution(), gameID, "")
 
 	// Verify the game can be reproduced with the same hash
-	h := game.VerifyGame(gameID, result.Hash)
-	if !h {
+	h := game.VerifyRound(game.DefaultDistribution(), gameID, result.Hash, "", testChain.TerminatingHash())
+	if !h.Valid || h.CrashPoint != result.CrashPoint {
 		t.Error("Game verification failed")
 	}
 
 	// Verify the game cannot be verified with wrong hash
-	h = game.VerifyGame(gameID, "wrong_hash")
-	if h {
+	h = game.VerifyRound(game.DefaultDistribution(), gameID, "wrong_hash", "", testChain.TerminatingHash())
+	if h.Valid {
 		t.Error("Game verification should fail with wrong hash")
 	}
 }
 
 func TestGameFairness(t *testing.T) {
-	crashPoints := make(map[float64]int)
 	iterations := 10000
+	var returned float64
+	target := 1.5
 
-	for i := 0; i < iterations; i++ {
-		result := game.GenerateNextGame(int64(i))
-		crashPoints[result.CrashPoint]++
+	for i := 1; i <= iterations; i++ {
+		result := testChain.Result(game.DefaultDistribution(), int64(i), "")
 
-		// Verify crash points are within expected range
-		if result.CrashPoint < 1.0 || result.CrashPoint > 10.0 {
-			t.Errorf("Crash point %f outside valid range [1.0, 10.0]", result.CrashPoint)
+		if result.CrashPoint < 1.0 {
+			t.Errorf("Crash point %f below minimum 1.0", result.CrashPoint)
 		}
-	}
 
-	// Check distribution
-	var total float64
-	for point, count := range crashPoints {
-		total += point * float64(count)
+		// Simulate a player always cashing out at the same target
+		if result.CrashPoint >= target {
+			returned += target
+		}
 	}
-	average := total / float64(iterations)
 
-	// Expected average for uniform distribution between 1 and 10
-	expectedAverage := 5.5
-	tolerance := 0.5
+	// Every target should return 1 - house edge on average
+	rtp := returned / float64(iterations)
+	expectedRTP := 1 - game.DefaultHouseEdge
+	tolerance := 0.03
 
-	if average < expectedAverage-tolerance || average > expectedAverage+tolerance {
-		t.Errorf("Unexpected average crash point: %f (expected around %f)", average, expectedAverage)
+	if rtp < expectedRTP-tolerance || rtp > expectedRTP+tolerance {
+		t.Errorf("Unexpected return to player: %f (expected around %f)", rtp, expectedRTP)
 	}
 }
@@ -1,6 +1,10 @@
 package tests
 
 import (
+	"crash-game/internal/clock"
+	"crash-game/internal/round"
+	"crash-game/internal/server"
+	"errors"
 	"testing"
 	"time"
 )
@@ -10,7 +14,7 @@ func TestGameStateTransitions(t *testing.T) {
 	defer ts.DB.Close()
 
 	// Wait for betting phase
-	WaitForGamePhase(t, ts.Server, "betting")
+	WaitForGamePhase(t, ts, round.Betting)
 
 	// Place a test bet
 	userID, _ := CreateTestUser(t, ts.DB)
@@ -20,7 +24,7 @@ func TestGameStateTransitions(t *testing.T) {
 	}
 
 	// Wait for game to start
-	WaitForGamePhase(t, ts.Server, "in_progress")
+	WaitForGamePhase(t, ts, round.InProgress)
 
 	// Verify game state
 	game := ts.Server.CurrentGame()
@@ -32,8 +36,8 @@ func TestGameStateTransitions(t *testing.T) {
 		t.Errorf("Expected 1 player, got %d", len(game.Players))
 	}
 
-	// Wait for game to end
-	WaitForGamePhase(t, ts.Server, "crashed")
+	// Wait for game to end and be recorded
+	WaitForGamePhase(t, ts, round.Settled)
 }
 
 func TestGameTimings(t *testing.T) {
@@ -41,14 +45,156 @@ func TestGameTimings(t *testing.T) {
 	defer ts.DB.Close()
 
 	// Wait for new game
-	WaitForGamePhase(t, ts.Server, "betting")
-	start := time.Now()
+	WaitForGamePhase(t, ts, round.Betting)
+	start := ts.Clock.Now()
 
 	// Wait for betting phase to end
-	WaitForGamePhase(t, ts.Server, "in_progress")
-	bettingDuration := time.Since(start)
+	WaitForGamePhase(t, ts, round.InProgress)
+	bettingDuration := ts.Clock.Since(start)
 
-	if bettingDuration < 4*time.Second || bettingDuration > 6*time.Second {
+	if bettingDuration != 5*time.Second {
 		t.Errorf("Unexpected betting phase duration: %v", bettingDuration)
 	}
 }
+
+func TestMultiplierFollowsClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := startedRound(start, 50)
+
+	tests := []struct {
+		elapsed  time.Duration
+		expected float64
+	}{
+		{0, 1.00},
+		{10 * time.Second, 2.71},
+		{30 * time.Second, 20.08},
+		{time.Minute, 50.00}, // past the crash instant
+	}
+
+	for _, tt := range tests {
+		if m := state.MultiplierAt(start.Add(tt.elapsed)); m != tt.expected {
+			t.Errorf("Multiplier after %v = %.2f, want %.2f", tt.elapsed, m, tt.expected)
+		}
+	}
+
+	state.Crash()
+	if m := state.MultiplierAt(start.Add(time.Minute)); m != 0 {
+		t.Errorf("Multiplier outside a running round = %.2f, want 0", m)
+	}
+}
+
+func TestCashoutSettlesAgainstTimeline(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := startedRound(start, 2.00)
+
+	// e^(0.1t) reaches 2.00 after ln(2)/0.1 seconds
+	if want := start.Add(6931471805 * time.Nanosecond); state.CrashTime.Sub(want).Abs() > time.Millisecond {
+		t.Errorf("Crash instant = %v, want %v", state.CrashTime, want)
+	}
+
+	m, err := state.CashoutMultiplier(start.Add(5 * time.Second))
+	if err != nil || m != 1.64 {
+		t.Errorf("Cashout after 5s = %.2f, %v; want 1.64", m, err)
+	}
+
+	m, err = state.CashoutMultiplier(state.CrashTime.Add(-time.Millisecond))
+	if err != nil || m >= state.CrashPoint {
+		t.Errorf("Cashout just before the crash = %.2f, %v; want below %.2f", m, err, state.CrashPoint)
+	}
+
+	// The loop may not have noticed the crash yet, but the timeline has
+	for _, at := range []time.Time{state.CrashTime, state.CrashTime.Add(50 * time.Millisecond)} {
+		if _, err := state.CashoutMultiplier(at); !errors.Is(err, server.ErrCashoutAfterCrash) {
+			t.Errorf("Cashout at %v error = %v, want ErrCashoutAfterCrash", at.Sub(start), err)
+		}
+	}
+}
+
+func TestMaxWinCashesOutBetsAtTheCap(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	target := 3.00
+	state := roundWithBets(start, 10.00, round.Config{MaxWin: 50}, map[string]*server.Player{
+		"big":   {UserID: "big", BetAmount: 25},
+		"small": {UserID: "small", BetAmount: 10, AutoCashout: &target},
+	})
+
+	state.SettleDueCashouts(state.MultiplierTime(3.50))
+
+	if big := state.Players["big"]; !big.CashedOut || big.Multiplier != 2.00 || big.WinAmount != 50 {
+		t.Errorf("Big bet = %+v, want cashed out at 2.00x for the max win of 50", big)
+	}
+	if small := state.Players["small"]; !small.CashedOut || small.Multiplier != 3.00 {
+		t.Errorf("Small bet = %+v, want its own auto-cashout at 3.00x", small)
+	}
+
+	forced := state.TakeForcedCashouts()
+	if len(forced) != 1 || forced[0].Reason != server.ForcedMaxWin || forced[0].Cashout.UserID != "big" {
+		t.Errorf("Forced cashouts = %+v, want only the big bet for max_win", forced)
+	}
+	if again := state.TakeForcedCashouts(); len(again) != 0 {
+		t.Errorf("Forced cashouts were returned twice: %+v", again)
+	}
+}
+
+func TestMaxPayoutCashesOutOpenBets(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	target := 1.50
+	state := roundWithBets(start, 10.00, round.Config{MaxPayout: 100}, map[string]*server.Player{
+		"early": {UserID: "early", BetAmount: 20, AutoCashout: &target},
+		"late":  {UserID: "late", BetAmount: 30},
+	})
+
+	// 50 staked would reach 100 at 2.00x, but the early cashout at 1.50x
+	// leaves 70 for the remaining 30 staked
+	state.SettleDueCashouts(state.MultiplierTime(2.10))
+	if late := state.Players["late"]; late.CashedOut {
+		t.Fatalf("Late bet cashed out at %.2fx, before the cap rose to 2.33x", late.Multiplier)
+	}
+
+	state.SettleDueCashouts(state.MultiplierTime(2.40))
+	late := state.Players["late"]
+	if !late.CashedOut || late.Multiplier != 2.33 {
+		t.Fatalf("Late bet = %+v, want cashed out at 2.33x", late)
+	}
+	if paid := state.Players["early"].WinAmount + late.WinAmount; paid > 100 {
+		t.Errorf("Round paid %.2f, more than its max payout of 100", paid)
+	}
+
+	forced := state.TakeForcedCashouts()
+	if len(forced) != 1 || forced[0].Reason != server.ForcedMaxPayout || forced[0].Cashout.UserID != "late" {
+		t.Errorf("Forced cashouts = %+v, want only the late bet for max_payout", forced)
+	}
+}
+
+// roundWithBets returns a running round with the given bets and payout
+// limits that started at start and crashes at crashPoint.
+func roundWithBets(start time.Time, crashPoint float64, limits round.Config, players map[string]*server.Player) *server.GameState {
+	cfg := round.DefaultConfig()
+	cfg.MaxWin, cfg.MaxPayout = limits.MaxWin, limits.MaxPayout
+	state := &server.GameState{
+		Machine:    round.New("test", clock.NewManual(start)),
+		CrashPoint: crashPoint,
+		Config:     cfg,
+		Players:    players,
+	}
+	state.OpenBetting()
+	state.CloseBetting()
+	state.StartTimeline(start)
+	state.Start()
+	return state
+}
+
+// startedRound returns a running round that started at start and crashes at
+// crashPoint.
+func startedRound(start time.Time, crashPoint float64) *server.GameState {
+	state := &server.GameState{
+		Machine:    round.New("test", clock.NewManual(start)),
+		CrashPoint: crashPoint,
+		Config:     round.DefaultConfig(),
+	}
+	state.OpenBetting()
+	state.CloseBetting()
+	state.StartTimeline(start)
+	state.Start()
+	return state
+}
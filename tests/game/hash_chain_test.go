@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"crash-game/internal/game"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestHashChainLinks(t *testing.T) {
+	chain := game.NewHashChain("test-seed", 100)
+
+	for round := int64(1); round <= chain.Length(); round++ {
+		hash, err := chain.Hash(round)
+		if err != nil {
+			t.Fatalf("Failed to get hash for round %d: %v", round, err)
+		}
+
+		// Hashing a round hash must give the previous round
+		previous := chain.TerminatingHash()
+		if round > 1 {
+			previous, _ = chain.Hash(round - 1)
+		}
+		sum := sha256.Sum256([]byte(hash))
+		if hex.EncodeToString(sum[:]) != previous {
+			t.Fatalf("Round %d does not link to round %d", round, round-1)
+		}
+
+		if !game.VerifyChainHash(hash, round, chain.TerminatingHash()) {
+			t.Errorf("Round %d failed verification against the terminating hash", round)
+		}
+	}
+}
+
+func TestHashChainConsumption(t *testing.T) {
+	chain := game.NewHashChain("test-seed", 3)
+
+	for expected := int64(1); expected <= 3; expected++ {
+		round, hash, err := chain.Next()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if round != expected {
+			t.Errorf("Expected round %d, got %d", expected, round)
+		}
+		if want, _ := chain.Hash(round); hash != want {
+			t.Errorf("Round %d returned the wrong hash", round)
+		}
+	}
+
+	if _, _, err := chain.Next(); err != game.ErrChainExhausted {
+		t.Errorf("Expected exhausted chain, got %v", err)
+	}
+
+	if err := chain.SetNextRound(2); err != nil {
+		t.Fatalf("Failed to resume chain: %v", err)
+	}
+	if round, _, _ := chain.Next(); round != 2 {
+		t.Errorf("Expected resumed round 2, got %d", round)
+	}
+}
+
+func TestVerifyRoundRejectsWrongPosition(t *testing.T) {
+	chain := game.NewHashChain("test-seed", 10)
+	hash, _ := chain.Hash(5)
+
+	if !game.VerifyRound(game.DefaultDistribution(), 5, hash, "", chain.TerminatingHash()).Valid {
+		t.Error("Round 5 should verify")
+	}
+	if game.VerifyRound(game.DefaultDistribution(), 4, hash, "", chain.TerminatingHash()).Valid {
+		t.Error("Hash of round 5 should not verify as round 4")
+	}
+}
+
+func TestVerifyChainLink(t *testing.T) {
+	chain := game.NewHashChain("test-seed", 10)
+	hash3, _ := chain.Hash(3)
+	hash7, _ := chain.Hash(7)
+
+	if !game.VerifyChainLink(hash7, 7, hash3, 3) {
+		t.Error("Round 7 should link to round 3")
+	}
+	if game.VerifyChainLink(hash7, 6, hash3, 3) {
+		t.Error("Hash of round 7 should not link as round 6")
+	}
+	if game.VerifyChainLink(hash3, 3, hash7, 7) {
+		t.Error("A round cannot be verified against a later anchor")
+	}
+	if !game.VerifyChainLink(hash3, 3, chain.TerminatingHash(), 0) {
+		t.Error("The terminating hash should anchor round 0")
+	}
+}
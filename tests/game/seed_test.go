@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"crash-game/internal/game"
+	"testing"
+)
+
+func TestSeedCommitmentDoesNotRevealChain(t *testing.T) {
+	serverSeed := "test-server-seed"
+	chain := game.NewSeedChain(serverSeed, 100)
+	commitment := game.HashServerSeed(serverSeed)
+
+	last, _ := chain.Hash(chain.Length())
+	if commitment == last || commitment == chain.TerminatingHash() {
+		t.Fatal("Seed commitment must not be part of the hash chain")
+	}
+
+	// Revealing the seed lets anyone rebuild the same chain
+	rebuilt := game.NewSeedChain(serverSeed, 100)
+	if rebuilt.TerminatingHash() != chain.TerminatingHash() {
+		t.Error("Revealed seed should rebuild the committed chain")
+	}
+}
+
+func TestCombineClientSeedsIgnoresOrder(t *testing.T) {
+	a := game.ClientSeedEntry{UserID: "a", ClientSeed: "lucky", Nonce: 3}
+	b := game.ClientSeedEntry{UserID: "b", ClientSeed: "seven", Nonce: 0}
+
+	first := game.CombineClientSeeds([]game.ClientSeedEntry{a, b})
+	second := game.CombineClientSeeds([]game.ClientSeedEntry{b, a})
+	if first != second {
+		t.Error("Round client seed should not depend on bet order")
+	}
+
+	b.Nonce++
+	if game.CombineClientSeeds([]game.ClientSeedEntry{a, b}) == first {
+		t.Error("A different nonce should give a different round client seed")
+	}
+
+	if game.CombineClientSeeds(nil) != "" {
+		t.Error("A round without bets should have an empty client seed")
+	}
+}
+
+func TestClientSeedChangesOutcome(t *testing.T) {
+	hash, _ := testChain.Hash(1)
+
+	if game.RoundDigest(hash, "") != hash {
+		t.Error("Empty client seed should use the chain hash as is")
+	}
+	if game.RoundDigest(hash, "abc") == game.RoundDigest(hash, "abd") {
+		t.Error("Different client seeds should give different digests")
+	}
+
+	result := testChain.Result(game.DefaultDistribution(), 1, "abc")
+	verification := game.VerifyRound(game.DefaultDistribution(), 1, hash, "abc", testChain.TerminatingHash())
+	if !verification.Valid || verification.CrashPoint != result.CrashPoint {
+		t.Error("Verification should reproduce the crash point for the client seed")
+	}
+}
+
+func TestValidateClientSeed(t *testing.T) {
+	valid := []string{"a", "my-seed_01"}
+	invalid := []string{"", "has space", "semi;colon", string(make([]byte, 65))}
+
+	for _, seed := range valid {
+		if err := game.ValidateClientSeed(seed); err != nil {
+			t.Errorf("Expected %q to be valid: %v", seed, err)
+		}
+	}
+	for _, seed := range invalid {
+		if err := game.ValidateClientSeed(seed); err == nil {
+			t.Errorf("Expected %q to be rejected", seed)
+		}
+	}
+}
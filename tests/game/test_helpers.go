@@ -1,7 +1,10 @@
 package tests
 
 import (
+	"crash-game/internal/clock"
 	"crash-game/internal/database"
+	"crash-game/internal/game"
+	"crash-game/internal/round"
 	"crash-game/internal/security"
 	"crash-game/internal/server"
 	"fmt"
@@ -11,9 +14,13 @@ import (
 	"github.com/google/uuid"
 )
 
+// testChain is a fixed chain so fairness tests are deterministic
+var testChain = game.NewSeedChain("test-server-seed", 10000)
+
 type TestGameServer struct {
 	Server *server.GameServer
 	DB     *database.Database
+	Clock  *clock.Manual
 }
 
 func SetupTestServer(t *testing.T) *TestGameServer {
@@ -22,17 +29,19 @@ func SetupTestServer(t *testing.T) *TestGameServer {
 		t.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	server := server.NewGameServer(db)
+	clk := clock.NewManual(time.Now())
+	server := server.NewGameServer(db, server.WithClock(clk))
 
 	// Start the game loop in a goroutine
 	go server.StartGameLoop()
 
-	// Wait for first game to initialize
-	time.Sleep(100 * time.Millisecond)
+	// Wait for the first betting phase to start
+	clk.BlockUntil(1)
 
 	return &TestGameServer{
 		Server: server,
 		DB:     db,
+		Clock:  clk,
 	}
 }
 
@@ -55,21 +64,18 @@ func CreateTestUser(t *testing.T, db *database.Database) (string, string) {
 	return user.ID, username
 }
 
-func WaitForGamePhase(t *testing.T, server *server.GameServer, phase string) {
-	timeout := time.After(30 * time.Second)
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-timeout:
-			t.Fatalf("Timeout waiting for game phase: %s", phase)
-		case <-ticker.C:
-			game := server.CurrentGame()
-			if game != nil && game.Status == phase {
-				time.Sleep(100 * time.Millisecond)
-				return
-			}
+// WaitForGamePhase advances the clock in game loop ticks until the current
+// round reaches phase.
+func WaitForGamePhase(t *testing.T, ts *TestGameServer, phase round.State) {
+	for step := 0; step < maxPhaseSteps; step++ {
+		ts.Clock.BlockUntil(1)
+		if game := ts.Server.CurrentGame(); game != nil && game.State() == phase {
+			return
 		}
+		ts.Clock.Advance(100 * time.Millisecond)
 	}
+	t.Fatalf("Timeout waiting for game phase: %s", phase)
 }
+
+// maxPhaseSteps bounds WaitForGamePhase to ten minutes of game time
+const maxPhaseSteps = 6000
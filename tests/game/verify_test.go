@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"crash-game/internal/game"
+	"testing"
+)
+
+func recordedRounds(from, to int64) []game.RoundRecord {
+	records := make([]game.RoundRecord, 0, to-from+1)
+	for round := from; round <= to; round++ {
+		result := testChain.Result(game.DefaultDistribution(), round, "seed")
+		records = append(records, game.RoundRecord{
+			Round:      round,
+			Hash:       result.Hash,
+			ClientSeed: "seed",
+			CrashPoint: result.CrashPoint,
+		})
+	}
+	return records
+}
+
+func TestRoundVerifierPassesRecordedRange(t *testing.T) {
+	records := recordedRounds(10, 50)
+
+	for _, v := range []game.RoundVerifier{
+		{TerminatingHash: testChain.TerminatingHash()},
+		{Chain: testChain},
+	} {
+		for _, check := range v.Verify(records) {
+			if !check.Pass || check.Chain != game.ChainOK {
+				t.Errorf("Round %d should pass, got chain %s: %s", check.Round, check.Chain, check.Error)
+			}
+		}
+	}
+}
+
+func TestRoundVerifierReportsMismatches(t *testing.T) {
+	records := recordedRounds(1, 20)
+	records[4].CrashPoint += 1
+	records[9].Hash = records[10].Hash
+
+	checks := game.RoundVerifier{TerminatingHash: testChain.TerminatingHash()}.Verify(records)
+
+	for _, check := range checks {
+		switch check.Round {
+		case 5:
+			if check.Pass || check.Chain != game.ChainOK {
+				t.Errorf("Round 5 has a wrong crash point and should fail on it alone, got %+v", check)
+			}
+		case 10:
+			if check.Pass || check.Chain != game.ChainMismatch {
+				t.Errorf("Round 10 has a wrong hash and should fail the chain check, got %+v", check)
+			}
+		default:
+			if !check.Pass {
+				t.Errorf("Round %d should still pass, got %+v", check.Round, check)
+			}
+		}
+	}
+}
+
+func TestRoundVerifierFailsUncheckedRounds(t *testing.T) {
+	checks := game.RoundVerifier{}.Verify(recordedRounds(5, 5))
+
+	if check := checks[0]; check.Pass || check.Chain != game.ChainUnchecked {
+		t.Errorf("Round without a chain or terminating hash should not pass, got %+v", check)
+	}
+}
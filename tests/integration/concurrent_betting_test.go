@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"crash-game/internal/clock"
 	"crash-game/internal/database"
+	"crash-game/internal/round"
 	"crash-game/internal/security"
 	"crash-game/internal/server"
 	"fmt"
@@ -15,6 +17,7 @@ import (
 type TestServer struct {
 	Server *server.GameServer
 	DB     *database.Database
+	Clock  *clock.Manual
 }
 
 func SetupTestServer(t *testing.T) *TestServer {
@@ -23,17 +26,19 @@ func SetupTestServer(t *testing.T) *TestServer {
 		t.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	server := server.NewGameServer(db)
+	clk := clock.NewManual(time.Now())
+	server := server.NewGameServer(db, server.WithClock(clk))
 
 	// Start the game loop in a goroutine
 	go server.StartGameLoop()
 
-	// Wait for first game to initialize
-	time.Sleep(100 * time.Millisecond)
+	// Wait for the first betting phase to start
+	clk.BlockUntil(1)
 
 	return &TestServer{
 		Server: server,
 		DB:     db,
+		Clock:  clk,
 	}
 }
 
@@ -48,7 +53,7 @@ func TestConcurrentBetting(t *testing.T) {
 	var userIDsMutex sync.Mutex
 
 	// Wait for betting phase
-	WaitForGamePhase(t, ts.Server, "betting")
+	WaitForGamePhase(t, ts, round.Betting)
 
 	// Create users and place bets concurrently
 	for i := 0; i < numUsers; i++ {
@@ -113,7 +118,7 @@ func TestConcurrentCashouts(t *testing.T) {
 	}
 
 	// Wait for betting phase and place bets
-	WaitForGamePhase(t, ts.Server, "betting")
+	WaitForGamePhase(t, ts, round.Betting)
 	for _, userID := range userIDs {
 		err := ts.Server.PlaceBetForTest(userID, 100.0, nil)
 		if err != nil {
@@ -122,7 +127,7 @@ func TestConcurrentCashouts(t *testing.T) {
 	}
 
 	// Wait for game to start
-	WaitForGamePhase(t, ts.Server, "in_progress")
+	WaitForGamePhase(t, ts, round.InProgress)
 
 	// Attempt concurrent cashouts
 	var wg sync.WaitGroup
@@ -161,20 +166,15 @@ func CreateTestUser(t *testing.T, db *database.Database) (string, string) {
 	return userID, username
 }
 
-func WaitForGamePhase(t *testing.T, server *server.GameServer, phase string) {
-	timeout := time.After(10 * time.Second)
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-timeout:
-			t.Fatalf("Timeout waiting for game phase: %s", phase)
-		case <-ticker.C:
-			currentGame := server.CurrentGame()
-			if currentGame != nil && currentGame.Status == phase {
-				return
-			}
+// WaitForGamePhase advances the clock in game loop ticks until the current
+// round reaches phase.
+func WaitForGamePhase(t *testing.T, ts *TestServer, phase round.State) {
+	for step := 0; step < 6000; step++ {
+		ts.Clock.BlockUntil(1)
+		if currentGame := ts.Server.CurrentGame(); currentGame != nil && currentGame.State() == phase {
+			return
 		}
+		ts.Clock.Advance(100 * time.Millisecond)
 	}
+	t.Fatalf("Timeout waiting for game phase: %s", phase)
 }
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"crash-game/internal/round"
+	"crash-game/internal/server"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPauseStopsAfterCurrentRound(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.DB.Close()
+
+	WaitForGamePhase(t, ts, round.Betting)
+	gameID := ts.Server.CurrentGame().GameID
+
+	if _, err := ts.Server.Pause(); err != nil {
+		t.Fatalf("Failed to pause: %v", err)
+	}
+	defer ts.Server.Resume()
+	if _, err := ts.Server.Pause(); err == nil {
+		t.Error("Pausing a paused game should fail")
+	}
+
+	// The current round still plays out
+	WaitForGamePhase(t, ts, round.Settled)
+
+	// Well past the cooldown, no new round has started
+	for i := 0; i < 100; i++ {
+		ts.Clock.BlockUntil(1)
+		ts.Clock.Advance(100 * time.Millisecond)
+	}
+	if current := ts.Server.CurrentGame(); current.GameID != gameID {
+		t.Fatalf("Round %s started while paused", current.GameID)
+	}
+
+	if _, err := ts.Server.Resume(); err != nil {
+		t.Fatalf("Failed to resume: %v", err)
+	}
+	WaitForGamePhase(t, ts, round.Betting)
+	if ts.Server.CurrentGame().GameID == gameID {
+		t.Error("Expected a new round after resuming")
+	}
+}
+
+func TestMaintenanceRejectsBets(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.DB.Close()
+
+	WaitForGamePhase(t, ts, round.Betting)
+
+	mode, err := ts.Server.EnterMaintenance("database upgrade")
+	if err != nil {
+		t.Fatalf("Failed to enter maintenance: %v", err)
+	}
+	defer ts.Server.Resume()
+	if mode.Mode != server.ModeMaintenance || mode.Message != "database upgrade" {
+		t.Errorf("Mode = %+v, want maintenance with its notice", mode)
+	}
+
+	if err := ts.Server.PlaceBetForTest(uuid.New().String(), 10, nil); err == nil {
+		t.Error("Expected bets to be rejected during maintenance")
+	}
+
+	// The round that was taking bets still finishes and settles
+	WaitForGamePhase(t, ts, round.Settled)
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"crash-game/internal/round"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCashoutScheduleOrdersByTarget(t *testing.T) {
+	s := round.NewCashoutSchedule()
+	s.Add("c", 3.0)
+	s.Add("a", 1.5)
+	s.Add("b", 2.0)
+	s.Add("b2", 2.0)
+
+	if next, ok := s.Next(); !ok || next.UserID != "a" {
+		t.Fatalf("Next() = %v, %v; want a", next, ok)
+	}
+
+	due := s.PopDue(func(o round.AutoCashout) bool { return o.Target <= 2.0 })
+	var got []string
+	for _, o := range due {
+		got = append(got, o.UserID)
+	}
+	if fmt.Sprint(got) != "[a b b2]" {
+		t.Errorf("PopDue(2.0) = %v, want [a b b2]", got)
+	}
+	if s.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", s.Len())
+	}
+
+	if due := s.PopDue(func(o round.AutoCashout) bool { return o.Target <= 2.99 }); len(due) != 0 {
+		t.Errorf("PopDue(2.99) = %v, want nothing", due)
+	}
+	if due := s.PopDue(func(o round.AutoCashout) bool { return true }); len(due) != 1 || due[0].UserID != "c" {
+		t.Errorf("PopDue(all) = %v, want [c]", due)
+	}
+	if _, ok := s.Next(); ok {
+		t.Error("Next() on an empty schedule should report nothing")
+	}
+}
+
+// BenchmarkAutoCashoutTick measures one game loop tick of a round with a
+// growing number of auto-cashouts, against scanning every player each tick.
+func BenchmarkAutoCashoutTick(b *testing.B) {
+	for _, players := range []int{100, 1000, 10000, 100000} {
+		targets := autoCashoutTargets(players)
+
+		b.Run(fmt.Sprintf("schedule/players=%d", players), func(b *testing.B) {
+			schedule, tick := newSchedule(targets), 0
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				reached := tickMultiplier(tick)
+				schedule.PopDue(func(o round.AutoCashout) bool { return o.Target <= reached })
+				tick++
+
+				if schedule.Len() == 0 {
+					b.StopTimer()
+					schedule, tick = newSchedule(targets), 0
+					b.StartTimer()
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("scan/players=%d", players), func(b *testing.B) {
+			cashedOut := make(map[string]bool, players)
+			tick, remaining := 0, players
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				reached := tickMultiplier(tick)
+				for userID, target := range targets {
+					if !cashedOut[userID] && target <= reached {
+						cashedOut[userID] = true
+						remaining--
+					}
+				}
+				tick++
+
+				if remaining == 0 {
+					b.StopTimer()
+					cashedOut, tick, remaining = make(map[string]bool, players), 0, players
+					b.StartTimer()
+				}
+			}
+		})
+	}
+}
+
+// tickMultiplier is the multiplier of the default curve after tick 100ms
+// ticks.
+func tickMultiplier(tick int) float64 {
+	return math.Exp(0.1 * float64(tick) / 10)
+}
+
+func autoCashoutTargets(players int) map[string]float64 {
+	rng := rand.New(rand.NewSource(1))
+	targets := make(map[string]float64, players)
+	for i := 0; i < players; i++ {
+		targets[fmt.Sprintf("player-%d", i)] = 1.01 + rng.Float64()*9
+	}
+	return targets
+}
+
+func newSchedule(targets map[string]float64) *round.CashoutSchedule {
+	schedule := round.NewCashoutSchedule()
+	for userID, target := range targets {
+		schedule.Add(userID, target)
+	}
+	return schedule
+}
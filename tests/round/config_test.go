@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"crash-game/internal/round"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConfigJSONPartialUpdate(t *testing.T) {
+	cfg := round.DefaultConfig()
+	if err := json.Unmarshal([]byte(`{"bettingDuration":"10s","curve":"exponential:0.2","maxWin":5000}`), &cfg); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if cfg.BettingDuration != 10*time.Second {
+		t.Errorf("BettingDuration = %v, want 10s", cfg.BettingDuration)
+	}
+	if cfg.CooldownDuration != 2*time.Second || cfg.TickInterval != 100*time.Millisecond {
+		t.Errorf("Omitted fields changed: cooldown %v, tick %v", cfg.CooldownDuration, cfg.TickInterval)
+	}
+	if cfg.Curve.Spec() != "exponential:0.2" {
+		t.Errorf("Curve = %s, want exponential:0.2", cfg.Curve.Spec())
+	}
+	if cfg.MaxWin != 5000 || cfg.MaxPayout != 0 {
+		t.Errorf("Limits = %.2f/%.2f, want max win 5000 and no max payout", cfg.MaxWin, cfg.MaxPayout)
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded round.Config
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Round trip failed: %v", err)
+	}
+	if decoded.BettingDuration != cfg.BettingDuration || decoded.Curve.Spec() != cfg.Curve.Spec() || decoded.MaxWin != cfg.MaxWin {
+		t.Errorf("Round trip = %s, want %s", data, data)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := round.DefaultConfig().Validate(); err != nil {
+		t.Fatalf("Default config is invalid: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*round.Config)
+	}{
+		{"short betting", func(c *round.Config) { c.BettingDuration = 500 * time.Millisecond }},
+		{"long betting", func(c *round.Config) { c.BettingDuration = time.Hour }},
+		{"negative cooldown", func(c *round.Config) { c.CooldownDuration = -time.Second }},
+		{"fast tick", func(c *round.Config) { c.TickInterval = time.Millisecond }},
+		{"slow tick", func(c *round.Config) { c.TickInterval = 2 * time.Second }},
+		{"no curve", func(c *round.Config) { c.Curve = nil }},
+		{"no bet slots", func(c *round.Config) { c.BetSlots = 0 }},
+		{"too many bet slots", func(c *round.Config) { c.BetSlots = 11 }},
+		{"negative max win", func(c *round.Config) { c.MaxWin = -1 }},
+		{"negative max payout", func(c *round.Config) { c.MaxPayout = -1 }},
+	}
+
+	for _, tt := range tests {
+		cfg := round.DefaultConfig()
+		tt.modify(&cfg)
+		if err := cfg.Validate(); !errors.Is(err, round.ErrInvalidConfig) {
+			t.Errorf("%s: Validate() = %v, want ErrInvalidConfig", tt.name, err)
+		}
+	}
+
+	cfg := round.DefaultConfig()
+	if err := json.Unmarshal([]byte(`{"tickInterval":"soon"}`), &cfg); err == nil {
+		t.Error("Unmarshal accepted an invalid duration")
+	}
+}
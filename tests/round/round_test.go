@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"crash-game/internal/clock"
+	"crash-game/internal/round"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newMachine(listeners ...round.Listener) *round.Machine {
+	return round.New("round-1", clock.NewManual(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), listeners...)
+}
+
+func TestRoundLifecycleEmitsEvents(t *testing.T) {
+	var events []round.Event
+	m := newMachine(func(e round.Event) { events = append(events, e) })
+
+	steps := []struct {
+		transition func() error
+		state      round.State
+		event      round.EventType
+	}{
+		{m.OpenBetting, round.Betting, round.EventBettingOpen},
+		{m.CloseBetting, round.Closed, round.EventBettingClosed},
+		{m.Start, round.InProgress, round.EventStarted},
+		{m.Crash, round.Crashed, round.EventCrashed},
+		{m.Settle, round.Settled, round.EventSettled},
+	}
+
+	for i, step := range steps {
+		from := m.State()
+		if err := step.transition(); err != nil {
+			t.Fatalf("Transition to %s failed: %v", step.state, err)
+		}
+		if m.State() != step.state {
+			t.Errorf("State = %s, want %s", m.State(), step.state)
+		}
+
+		e := events[i]
+		if e.Type != step.event || e.From != from || e.To != step.state || e.RoundID != "round-1" {
+			t.Errorf("Unexpected event %+v for transition to %s", e, step.state)
+		}
+	}
+
+	if len(events) != len(steps) {
+		t.Errorf("Expected %d events, got %d", len(steps), len(events))
+	}
+}
+
+func TestRoundRejectsInvalidTransitions(t *testing.T) {
+	var events int
+	m := newMachine(func(round.Event) { events++ })
+
+	err := m.Start()
+	if !errors.Is(err, round.ErrInvalidTransition) {
+		t.Fatalf("Expected ErrInvalidTransition, got %v", err)
+	}
+
+	var transitionErr *round.TransitionError
+	if !errors.As(err, &transitionErr) || transitionErr.From != round.Waiting || transitionErr.To != round.InProgress {
+		t.Errorf("Unexpected transition error %+v", transitionErr)
+	}
+	if m.State() != round.Waiting || events != 0 {
+		t.Errorf("A rejected transition must not change state or emit events")
+	}
+
+	m.OpenBetting()
+	if err := m.OpenBetting(); !errors.Is(err, round.ErrInvalidTransition) {
+		t.Errorf("Opening betting twice should fail, got %v", err)
+	}
+}
+
+func TestRoundRequire(t *testing.T) {
+	m := newMachine()
+	m.OpenBetting()
+
+	if err := m.Require(round.Betting); err != nil {
+		t.Errorf("Require(betting) = %v, want nil", err)
+	}
+
+	err := m.Require(round.InProgress)
+	var stateErr *round.StateError
+	if !errors.Is(err, round.ErrWrongState) || !errors.As(err, &stateErr) || stateErr.Got != round.Betting {
+		t.Errorf("Require(in_progress) = %v, want a StateError from betting", err)
+	}
+}
+
+func TestFinishedStates(t *testing.T) {
+	for state, finished := range map[round.State]bool{
+		round.Waiting:    false,
+		round.Betting:    false,
+		round.Closed:     false,
+		round.InProgress: false,
+		round.Crashed:    true,
+		round.Settled:    true,
+	} {
+		if state.Finished() != finished {
+			t.Errorf("%s.Finished() = %v, want %v", state, state.Finished(), finished)
+		}
+	}
+}
@@ -128,6 +128,140 @@ func TestDoubleBetPrevention(t *testing.T) {
 	}
 }
 
+func TestBetPersistedWithDebit(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.DB.Close()
+
+	_, username := CreateTestUser(t, ts.DB)
+	token := loginUser(t, username)
+
+	WaitForGamePhase(t, token, "betting")
+
+	first := placeBetHTTP(t, token, 10.0, nil)
+	if first == 0 {
+		t.Fatal("Expected the bet response to carry the ID of the persisted bet")
+	}
+
+	// The next round's bet is a new row
+	WaitForGamePhase(t, token, "crashed")
+	WaitForGamePhase(t, token, "betting")
+
+	if second := placeBetHTTP(t, token, 10.0, nil); second <= first {
+		t.Errorf("Expected a new bet row after %d, got %d", first, second)
+	}
+}
+
+func TestBetSlots(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.DB.Close()
+
+	_, username := CreateTestUser(t, ts.DB)
+	token := loginUser(t, username)
+
+	WaitForGamePhase(t, token, "betting")
+
+	// Each slot holds a bet of its own
+	first, status := placeBetInSlot(t, token, 10.0, 0)
+	if status != http.StatusOK {
+		t.Fatalf("Bet in slot 0 failed, status: %d", status)
+	}
+	second, status := placeBetInSlot(t, token, 20.0, 1)
+	if status != http.StatusOK {
+		t.Fatalf("Bet in slot 1 failed, status: %d", status)
+	}
+	if first == second {
+		t.Errorf("Expected the slots to have bets rows of their own, both got %d", first)
+	}
+
+	// A taken slot and a slot beyond the round config are rejected
+	if _, status := placeBetInSlot(t, token, 10.0, 1); status != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a second bet in slot 1, got %d", http.StatusBadRequest, status)
+	}
+	if _, status := placeBetInSlot(t, token, 10.0, 2); status != http.StatusBadRequest {
+		t.Errorf("Expected status %d for slot 2, got %d", http.StatusBadRequest, status)
+	}
+}
+
+func TestCancelBet(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.DB.Close()
+
+	_, username := CreateTestUser(t, ts.DB)
+	token := loginUser(t, username)
+	user, err := ts.DB.GetUserByUsername(username)
+	if err != nil {
+		t.Fatalf("Failed to look up test user: %v", err)
+	}
+
+	WaitForGamePhase(t, token, "betting")
+	before, _ := ts.DB.GetUserBalance(user.ID)
+
+	placeBetHTTP(t, token, 10.0, nil)
+	if status := cancelBetHTTP(t, token, 0); status != http.StatusOK {
+		t.Fatalf("Expected the bet to be cancelled, status: %d", status)
+	}
+	if after, _ := ts.DB.GetUserBalance(user.ID); after != before {
+		t.Errorf("Balance after cancelling = %.2f, want %.2f", after, before)
+	}
+
+	// The bet is gone and the slot is free again
+	if status := cancelBetHTTP(t, token, 0); status != http.StatusNotFound {
+		t.Errorf("Expected status %d cancelling a cancelled bet, got %d", http.StatusNotFound, status)
+	}
+	placeBetHTTP(t, token, 10.0, nil)
+
+	// Once betting closes the bet stays
+	WaitForGamePhase(t, token, "in_progress")
+	if status := cancelBetHTTP(t, token, 0); status != http.StatusConflict {
+		t.Errorf("Expected status %d cancelling after betting closed, got %d", http.StatusConflict, status)
+	}
+}
+
+func TestQueueBetForNextRound(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.DB.Close()
+
+	_, username := CreateTestUser(t, ts.DB)
+	token := loginUser(t, username)
+	user, err := ts.DB.GetUserByUsername(username)
+	if err != nil {
+		t.Fatalf("Failed to look up test user: %v", err)
+	}
+
+	WaitForGamePhase(t, token, "in_progress")
+
+	// A queued bet can be cancelled and queued again
+	if status := queueBetHTTP(t, token, 10.0); status != http.StatusAccepted {
+		t.Fatalf("Expected the bet to be queued, status: %d", status)
+	}
+	if status := queueBetHTTP(t, token, 10.0); status != http.StatusBadRequest {
+		t.Errorf("Expected status %d queueing a second bet in the slot, got %d", http.StatusBadRequest, status)
+	}
+	if status := cancelBetHTTP(t, token, 0); status != http.StatusOK {
+		t.Fatalf("Expected the queued bet to be cancelled, status: %d", status)
+	}
+	if status := queueBetHTTP(t, token, 10.0); status != http.StatusAccepted {
+		t.Fatalf("Expected the bet to be queued again, status: %d", status)
+	}
+
+	// It is placed once the next round opens betting
+	WaitForGamePhase(t, token, "betting")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		game := getGameStateHTTP(t, token)
+		if bet, ok := game.Players[user.ID]; ok {
+			if bet.BetAmount != 10.0 {
+				t.Errorf("Queued bet amount = %.2f, want 10", bet.BetAmount)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Queued bet was not placed in the next round")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
 func WaitForGamePhase(t *testing.T, token string, phase string) {
 	timeout := time.After(30 * time.Second)
 	ticker := time.NewTicker(100 * time.Millisecond)
@@ -197,7 +331,8 @@ func loginUser(t *testing.T, username string) string {
 	return authResp.Token
 }
 
-func placeBetHTTP(t *testing.T, token string, amount float64, autoCashout *float64) {
+// placeBetHTTP places a bet and returns the ID of its bets row.
+func placeBetHTTP(t *testing.T, token string, amount float64, autoCashout *float64) int {
 	betData := struct {
 		Amount      float64  `json:"amount"`
 		AutoCashout *float64 `json:"auto_cashout,omitempty"`
@@ -221,4 +356,62 @@ func placeBetHTTP(t *testing.T, token string, amount float64, autoCashout *float
 	if resp.StatusCode != http.StatusOK {
 		t.Fatalf("Failed to place bet, status: %d", resp.StatusCode)
 	}
+
+	var bet struct {
+		BetID int `json:"betId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&bet); err != nil {
+		t.Fatalf("Failed to decode bet response: %v", err)
+	}
+	return bet.BetID
+}
+
+// placeBetInSlot places a bet in a bet slot and returns the ID of its bets
+// row and the response status.
+func placeBetInSlot(t *testing.T, token string, amount float64, slot int) (int, int) {
+	betJSON, _ := json.Marshal(map[string]interface{}{"amount": amount, "slot": slot})
+	req, _ := http.NewRequest("POST", "http://localhost:8080/api/bet", bytes.NewBuffer(betJSON))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to place bet: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var bet struct {
+		BetID int `json:"betId"`
+	}
+	json.NewDecoder(resp.Body).Decode(&bet)
+	return bet.BetID, resp.StatusCode
+}
+
+// cancelBetHTTP cancels the bet in a slot and returns the response status.
+func cancelBetHTTP(t *testing.T, token string, slot int) int {
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("http://localhost:8080/api/bet?slot=%d", slot), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to cancel bet: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// queueBetHTTP places a bet, queued for the next round if betting is closed,
+// and returns the response status.
+func queueBetHTTP(t *testing.T, token string, amount float64) int {
+	betJSON, _ := json.Marshal(map[string]interface{}{"amount": amount, "queue": true})
+	req, _ := http.NewRequest("POST", "http://localhost:8080/api/bet", bytes.NewBuffer(betJSON))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to queue bet: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
 }
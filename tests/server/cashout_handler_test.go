@@ -240,3 +240,63 @@ func cashoutHTTP(t *testing.T, token string) float64 {
 	}
 	return result.Multiplier
 }
+
+func TestPartialCashout(t *testing.T) {
+	ts := SetupCashoutTestServer(t)
+	defer ts.DB.Close()
+
+	_, username := CreateCashoutTestUser(t, ts.DB)
+	token := loginCashoutUser(t, username)
+
+	WaitForCashoutGamePhase(t, token, "betting")
+	placeCashoutBetHTTP(t, token, 100.0, nil)
+	WaitForCashoutGamePhase(t, token, "in_progress")
+	time.Sleep(time.Second)
+
+	// Half the stake is taken, the rest keeps riding
+	first := cashoutPartHTTP(t, token, `{"fraction":0.5}`)
+	if first.Stake != 50 || first.CashedOut {
+		t.Errorf("First cashout = %+v, want 50 of the stake with the bet still open", first)
+	}
+
+	second := cashoutPartHTTP(t, token, `{"amount":20}`)
+	if second.Stake != 20 || second.CashedOut || second.Multiplier < first.Multiplier {
+		t.Errorf("Second cashout = %+v, want 20 more of the stake at a later multiplier", second)
+	}
+
+	// Without a portion the rest of the bet is cashed out
+	last := cashoutPartHTTP(t, token, ``)
+	if last.Stake != 30 || !last.CashedOut {
+		t.Errorf("Last cashout = %+v, want the remaining 30 and the bet closed", last)
+	}
+}
+
+type cashoutResult struct {
+	Multiplier float64 `json:"multiplier"`
+	Stake      float64 `json:"stake"`
+	WinAmount  float64 `json:"winAmount"`
+	CashedOut  bool    `json:"cashedOut"`
+}
+
+func cashoutPartHTTP(t *testing.T, token string, body string) cashoutResult {
+	req, _ := http.NewRequest("POST", "http://localhost:8080/api/cashout", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send cashout request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("Failed to cashout, status: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var result cashoutResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode cashout response: %v", err)
+	}
+	return result
+}
@@ -3,12 +3,15 @@ package tests
 import (
 	"bytes"
 	"crash-game/internal/database"
+	"crash-game/internal/models"
 	"crash-game/internal/server"
 	"encoding/json"
 	"io"
 	"net/http"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type GameHistoryTestServer struct {
@@ -235,3 +238,62 @@ func WaitForGamePhaseHTTP(t *testing.T, token string, phase string) {
 		}
 	}
 }
+
+func TestGameHistoryListsRoundOnceForSeveralSlots(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.DB.Close()
+
+	_, username := CreateTestUser(t, ts.DB)
+	user, err := ts.DB.GetUserByUsername(username)
+	if err != nil {
+		t.Fatalf("Failed to look up test user: %v", err)
+	}
+	seed, err := ts.DB.GetActiveServerSeed("main")
+	if err != nil {
+		t.Fatalf("Failed to get active server seed: %v", err)
+	}
+
+	// A crashed round the user bet in with two slots
+	start := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	round := &models.GameHistory{
+		GameID:       uuid.New().String(),
+		RoomID:       "main",
+		Round:        start.UnixNano(),
+		ServerSeedID: seed.ID,
+		StartTime:    start,
+		Hash:         "history-slots-test",
+	}
+	if err := ts.DB.CreateRound(round); err != nil {
+		t.Fatalf("Failed to create round: %v", err)
+	}
+	for slot := 0; slot < 2; slot++ {
+		bet := &models.PlayerHistory{UserID: user.ID, Slot: slot, BetAmount: 10}
+		if err := ts.DB.PlaceBet(round.GameID, seed.ID, bet); err != nil {
+			t.Fatalf("Failed to place bet in slot %d: %v", slot, err)
+		}
+	}
+	if err := ts.DB.StartRound(round.GameID, "", 1.5, start); err != nil {
+		t.Fatalf("Failed to start round: %v", err)
+	}
+	crash := start.Add(5 * time.Second)
+	err = ts.DB.SettleRound(&models.Settlement{
+		GameID: round.GameID, CrashPoint: 1.5, CrashTime: crash, Bets: 2, Wagered: 20, Attempts: 1, SettledAt: crash,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to settle round: %v", err)
+	}
+
+	history, err := ts.DB.GetGameHistory("main")
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	listed := 0
+	for _, h := range history {
+		if h.GameID == round.GameID {
+			listed++
+		}
+	}
+	if listed != 1 {
+		t.Errorf("Round listed %d times, want once", listed)
+	}
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"crash-game/internal/database"
+	"crash-game/internal/models"
+	"crash-game/internal/server"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPlanRecoveryRefundsRoundsWithoutCrashPoint(t *testing.T) {
+	round := models.GameHistory{
+		GameID:  "betting-round",
+		Status:  "betting",
+		Players: []models.PlayerHistory{{UserID: "a", BetAmount: 10}},
+	}
+	if plan := server.PlanRecovery(round); !plan.Refund {
+		t.Error("A round still taking bets should be refunded")
+	}
+}
+
+func TestPlanRecoverySettlesFromCommittedCrashPoint(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	target := func(m float64) *float64 { return &m }
+	cashoutAt := start.Add(3 * time.Second)
+
+	round := models.GameHistory{
+		GameID:     "running-round",
+		Status:     "in_progress",
+		Curve:      "exponential:0.1",
+		CrashPoint: 3.00,
+		StartTime:  start,
+		Players: []models.PlayerHistory{
+			{BetID: 1, UserID: "manual", BetAmount: 10, CashedOut: true, CashoutMultiplier: 1.34, WinAmount: 13.4, CashoutAt: &cashoutAt},
+			{BetID: 2, UserID: "auto-win", BetAmount: 10, AutoCashout: target(2.00)},
+			{BetID: 3, UserID: "auto-lose", BetAmount: 10, AutoCashout: target(3.00)},
+			{BetID: 4, UserID: "no-cashout", BetAmount: 10},
+		},
+	}
+
+	plan := server.PlanRecovery(round)
+	if plan.Refund {
+		t.Fatal("A started round should be settled, not refunded")
+	}
+
+	wantEnd := start.Add(time.Duration(math.Log(3) / 0.1 * float64(time.Second)))
+	if d := plan.Settlement.CrashTime.Sub(wantEnd); d.Abs() > time.Millisecond {
+		t.Errorf("CrashTime = %v, want %v", plan.Settlement.CrashTime, wantEnd)
+	}
+
+	if len(plan.Cashouts) != 2 {
+		t.Fatalf("Cashouts = %+v, want the manual cashout and the auto-cashout below the crash point", plan.Cashouts)
+	}
+	if manual := plan.Cashouts[0]; manual.BetID != 1 || manual.WinAmount != 13.4 || !manual.At.Equal(cashoutAt) {
+		t.Errorf("Cashout = %+v, want manual paid 13.4 as recorded", manual)
+	}
+	auto := plan.Cashouts[1]
+	if auto.BetID != 2 || auto.Multiplier != 2.00 || auto.WinAmount != 20 {
+		t.Errorf("Cashout = %+v, want auto-win paid 20 at 2.00x", auto)
+	}
+	wantAt := start.Add(time.Duration(math.Log(2) / 0.1 * float64(time.Second)))
+	if d := auto.At.Sub(wantAt); d.Abs() > time.Millisecond {
+		t.Errorf("Cashout at %v, want %v", auto.At, wantAt)
+	}
+	if round.Players[1].CashedOut {
+		t.Error("Planning a recovery should not change the round's bets")
+	}
+
+	// Recovery is deterministic
+	again := server.PlanRecovery(round)
+	if again.Settlement != plan.Settlement || len(again.Cashouts) != 2 || again.Cashouts[1] != auto {
+		t.Error("Planning the same recovery twice gave different results")
+	}
+}
+
+func TestPlanRecoveryKeepsPayoutLimits(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	target := func(m float64) *float64 { return &m }
+
+	// The round crashed at 5.00x. The max win of 30 cashes the stakes of 20
+	// out at 1.50x, which leaves 40 of the max payout of 100 for the 20
+	// still staked, so the other two bets are cashed out at 2.00x
+	round := models.GameHistory{
+		GameID:     "capped-round",
+		Status:     "in_progress",
+		Curve:      "exponential:0.1",
+		CrashPoint: 5.00,
+		StartTime:  start,
+		MaxWin:     30,
+		MaxPayout:  100,
+		Players: []models.PlayerHistory{
+			{BetID: 1, UserID: "auto", BetAmount: 10, AutoCashout: target(4.00)},
+			{BetID: 2, UserID: "no-cashout", BetAmount: 10},
+			{BetID: 3, UserID: "auto", Slot: 1, BetAmount: 20, AutoCashout: target(4.50)},
+			{BetID: 4, UserID: "big", BetAmount: 20, AutoCashout: target(4.50)},
+		},
+	}
+
+	plan := server.PlanRecovery(round)
+	if plan.Refund {
+		t.Fatal("A started round should be settled, not refunded")
+	}
+
+	want := map[int]float64{1: 2.00, 2: 2.00, 3: 1.50, 4: 1.50}
+	if len(plan.Cashouts) != len(want) {
+		t.Fatalf("Cashouts = %+v, want one for every bet", plan.Cashouts)
+	}
+	for _, cashout := range plan.Cashouts {
+		if cashout.Multiplier != want[cashout.BetID] {
+			t.Errorf("Bet %d cashed out at %.2fx, want %.2fx", cashout.BetID, cashout.Multiplier, want[cashout.BetID])
+		}
+		if cashout.WinAmount > round.MaxWin {
+			t.Errorf("Bet %d won %.2f, more than the max win of %.2f", cashout.BetID, cashout.WinAmount, round.MaxWin)
+		}
+	}
+	if plan.Settlement.Paid > round.MaxPayout {
+		t.Errorf("Round paid %.2f, more than its max payout of %.2f", plan.Settlement.Paid, round.MaxPayout)
+	}
+}
+
+func TestRefundRoundReturnsStakesOfCashedOutBets(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.DB.Close()
+
+	seed, err := ts.DB.GetActiveServerSeed("main")
+	if err != nil {
+		t.Fatalf("Failed to get active server seed: %v", err)
+	}
+
+	// A round interrupted before its crash point was committed, with a bet
+	// cashed out in full and one cashed out in part
+	start := time.Now().UTC().Truncate(time.Second)
+	round := &models.GameHistory{
+		GameID:       uuid.New().String(),
+		Round:        start.UnixNano(),
+		ServerSeedID: seed.ID,
+		StartTime:    start,
+		Hash:         "refund-test",
+	}
+	if err := ts.DB.CreateRound(round); err != nil {
+		t.Fatalf("Failed to create round: %v", err)
+	}
+
+	var users []string
+	before := make(map[string]float64)
+	for i, cashout := range []database.Cashout{
+		{Seq: 1, Stake: 10, Multiplier: 2, WinAmount: 20, Final: true},
+		{Seq: 1, Stake: 5, Multiplier: 1.5, WinAmount: 7.5},
+	} {
+		_, username := CreateTestUser(t, ts.DB)
+		user, err := ts.DB.GetUserByUsername(username)
+		if err != nil {
+			t.Fatalf("Failed to look up test user: %v", err)
+		}
+		users = append(users, user.ID)
+		before[user.ID], _ = ts.DB.GetUserBalance(user.ID)
+
+		bet := &models.PlayerHistory{UserID: user.ID, BetAmount: 10}
+		if err := ts.DB.PlaceBet(round.GameID, seed.ID, bet); err != nil {
+			t.Fatalf("Failed to place bet %d: %v", i+1, err)
+		}
+		cashout.BetID, cashout.GameID, cashout.UserID, cashout.At = bet.BetID, round.GameID, user.ID, start
+		if err := ts.DB.RecordCashout(cashout); err != nil {
+			t.Fatalf("Failed to record cashout %d: %v", i+1, err)
+		}
+	}
+
+	refunded, err := ts.DB.RefundRound(round.GameID, start.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to refund round: %v", err)
+	}
+	if refunded != 20 {
+		t.Errorf("Refunded %.2f, want both stakes of 10", refunded)
+	}
+	for _, userID := range users {
+		if after, _ := ts.DB.GetUserBalance(userID); after != before[userID] {
+			t.Errorf("Balance of %s after refund = %.2f, want %.2f as before the round", userID, after, before[userID])
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"crash-game/internal/models"
+	"crash-game/internal/server"
+	"errors"
+	"testing"
+)
+
+func TestValidateRoom(t *testing.T) {
+	tests := []struct {
+		name  string
+		room  models.Room
+		valid bool
+	}{
+		{"main", models.Room{ID: "main", Name: "Main", MaxBet: 1000}, true},
+		{"high stakes", models.Room{ID: "high-stakes", Name: "High stakes", MinBet: 100, MaxBet: 10000}, true},
+		{"uppercase id", models.Room{ID: "Main", Name: "Main", MaxBet: 1000}, false},
+		{"spaces in id", models.Room{ID: "low stakes", Name: "Low", MaxBet: 10}, false},
+		{"leading dash", models.Room{ID: "-fast", Name: "Fast", MaxBet: 10}, false},
+		{"long id", models.Room{ID: "a-room-id-that-is-far-too-long-to-use", Name: "Long", MaxBet: 10}, false},
+		{"no name", models.Room{ID: "fast", MaxBet: 10}, false},
+		{"negative minimum", models.Room{ID: "fast", Name: "Fast", MinBet: -1, MaxBet: 10}, false},
+		{"no maximum", models.Room{ID: "fast", Name: "Fast"}, false},
+		{"maximum below minimum", models.Room{ID: "fast", Name: "Fast", MinBet: 100, MaxBet: 10}, false},
+	}
+
+	for _, tt := range tests {
+		err := server.ValidateRoom(tt.room)
+		if tt.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, server.ErrInvalidRoom) {
+			t.Errorf("%s: error = %v, want ErrInvalidRoom", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"crash-game/internal/database"
+	"crash-game/internal/models"
+	"crash-game/internal/server"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPlanSettlementPaysCashedOutBets(t *testing.T) {
+	crash := time.Date(2024, 1, 1, 0, 0, 10, 0, time.UTC)
+	cashoutAt := crash.Add(-5 * time.Second)
+
+	round := models.GameHistory{
+		GameID:     "crashed-round",
+		CrashPoint: 2.50,
+		EndTime:    crash,
+		Players: []models.PlayerHistory{
+			{BetID: 1, UserID: "winner", BetAmount: 10, CashedOut: true, CashoutMultiplier: 1.50, WinAmount: 15, CashoutAt: &cashoutAt},
+			{BetID: 2, UserID: "loser", BetAmount: 20},
+		},
+	}
+
+	settlement, cashouts := server.PlanSettlement(round)
+
+	want := models.Settlement{
+		GameID:     "crashed-round",
+		CrashPoint: 2.50,
+		CrashTime:  crash,
+		Bets:       2,
+		Winners:    1,
+		Wagered:    30,
+		Paid:       15,
+	}
+	if settlement != want {
+		t.Errorf("Settlement = %+v, want %+v", settlement, want)
+	}
+	if len(cashouts) != 1 || cashouts[0].BetID != 1 || cashouts[0].Multiplier != 1.50 || !cashouts[0].At.Equal(cashoutAt) {
+		t.Errorf("Cashouts = %+v, want the winner paid at 1.50x", cashouts)
+	}
+}
+
+func TestPlanSettlementPaysEveryPartialCashout(t *testing.T) {
+	crash := time.Date(2024, 1, 1, 0, 0, 10, 0, time.UTC)
+	first, second := crash.Add(-6*time.Second), crash.Add(-3*time.Second)
+
+	round := models.GameHistory{
+		GameID:     "partial-round",
+		CrashPoint: 3.00,
+		EndTime:    crash,
+		Players: []models.PlayerHistory{
+			// Half taken at 1.50x, the rest lost in the crash
+			{BetID: 1, UserID: "rider", BetAmount: 10, WinAmount: 7.5, Cashouts: []models.CashoutLine{
+				{Stake: 5, Multiplier: 1.50, WinAmount: 7.5, CashoutAt: first},
+			}},
+			// Half taken at 1.50x, the rest at 2.00x
+			{BetID: 2, UserID: "closer", BetAmount: 10, WinAmount: 17.5, CashedOut: true, Cashouts: []models.CashoutLine{
+				{Stake: 5, Multiplier: 1.50, WinAmount: 7.5, CashoutAt: first},
+				{Stake: 5, Multiplier: 2.00, WinAmount: 10, CashoutAt: second},
+			}},
+		},
+	}
+
+	settlement, cashouts := server.PlanSettlement(round)
+
+	if settlement.Winners != 2 || settlement.Paid != 25 || settlement.Wagered != 20 {
+		t.Errorf("Settlement = %+v, want 2 winners paid 25 of 20 wagered", settlement)
+	}
+	if len(cashouts) != 3 {
+		t.Fatalf("Cashouts = %+v, want one per cashout line", cashouts)
+	}
+	if rider := cashouts[0]; rider.BetID != 1 || rider.Seq != 1 || rider.Stake != 5 || rider.Final {
+		t.Errorf("Cashout = %+v, want the rider's partial cashout leaving the bet open", rider)
+	}
+	if last := cashouts[2]; last.BetID != 2 || last.Seq != 2 || last.WinAmount != 10 || !last.Final || !last.At.Equal(second) {
+		t.Errorf("Cashout = %+v, want the closer's second line closing the bet", last)
+	}
+}
+
+func TestSettleBackoffDoublesUpToCap(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 500 * time.Millisecond},
+		{2, time.Second},
+		{3, 2 * time.Second},
+		{7, 30 * time.Second},
+		{50, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := server.SettleBackoff(tt.attempt); got != tt.want {
+			t.Errorf("SettleBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestSettleRoundRejectsRoundsNotStarted(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.DB.Close()
+
+	seed, err := ts.DB.GetActiveServerSeed("main")
+	if err != nil {
+		t.Fatalf("Failed to get active server seed: %v", err)
+	}
+
+	// A round whose crash point was never committed
+	start := time.Now().UTC().Truncate(time.Second)
+	round := &models.GameHistory{
+		GameID:       uuid.New().String(),
+		Round:        start.UnixNano(),
+		ServerSeedID: seed.ID,
+		StartTime:    start,
+		Hash:         "unstarted-test",
+	}
+	if err := ts.DB.CreateRound(round); err != nil {
+		t.Fatalf("Failed to create round: %v", err)
+	}
+
+	settlement := &models.Settlement{GameID: round.GameID, CrashPoint: 2, CrashTime: start, Attempts: 1, SettledAt: start}
+	if err := ts.DB.SettleRound(settlement, nil); !errors.Is(err, database.ErrRoundNotStarted) {
+		t.Fatalf("SettleRound() = %v, want ErrRoundNotStarted", err)
+	}
+
+	// The round is still refunded by recovery
+	if _, err := ts.DB.RefundRound(round.GameID, start); err != nil {
+		t.Fatalf("Failed to refund round: %v", err)
+	}
+	if err := ts.DB.SettleRound(settlement, nil); !errors.Is(err, database.ErrRoundSettled) {
+		t.Errorf("SettleRound() of a refunded round = %v, want ErrRoundSettled", err)
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"crash-game/internal/database"
+	"crash-game/internal/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestVoidRoundRefundsStakesAndReversesWins(t *testing.T) {
+	ts := SetupTestServer(t)
+	defer ts.DB.Close()
+
+	_, username := CreateTestUser(t, ts.DB)
+	user, err := ts.DB.GetUserByUsername(username)
+	if err != nil {
+		t.Fatalf("Failed to look up test user: %v", err)
+	}
+	seed, err := ts.DB.GetActiveServerSeed("main")
+	if err != nil {
+		t.Fatalf("Failed to get active server seed: %v", err)
+	}
+	before, _ := ts.DB.GetUserBalance(user.ID)
+
+	// A round the user won 2x in, settled
+	start := time.Now().UTC().Truncate(time.Second)
+	round := &models.GameHistory{
+		GameID:       uuid.New().String(),
+		Round:        start.UnixNano(),
+		ServerSeedID: seed.ID,
+		StartTime:    start,
+		Hash:         "void-test",
+	}
+	if err := ts.DB.CreateRound(round); err != nil {
+		t.Fatalf("Failed to create round: %v", err)
+	}
+	bet := &models.PlayerHistory{UserID: user.ID, BetAmount: 10}
+	if err := ts.DB.PlaceBet(round.GameID, seed.ID, bet); err != nil {
+		t.Fatalf("Failed to place bet: %v", err)
+	}
+	if err := ts.DB.StartRound(round.GameID, "", 3.00, start); err != nil {
+		t.Fatalf("Failed to start round: %v", err)
+	}
+	crash := start.Add(10 * time.Second)
+	err = ts.DB.SettleRound(&models.Settlement{
+		GameID: round.GameID, CrashPoint: 3.00, CrashTime: crash, Bets: 1, Winners: 1,
+		Wagered: 10, Paid: 20, Attempts: 1, SettledAt: crash,
+	}, []database.Cashout{{BetID: bet.BetID, GameID: round.GameID, UserID: user.ID, Multiplier: 2, WinAmount: 20, At: crash}})
+	if err != nil {
+		t.Fatalf("Failed to settle round: %v", err)
+	}
+
+	void := &models.RoundVoid{GameID: round.GameID, Reason: "test", VoidedAt: crash.Add(time.Minute)}
+	if err := ts.DB.VoidRound(void); err != nil {
+		t.Fatalf("Failed to void round: %v", err)
+	}
+	if void.Bets != 1 || void.Refunded != 10 || void.Reversed != 20 {
+		t.Errorf("Void = %+v, want the stake of 10 refunded and the win of 20 reversed", void)
+	}
+	if after, _ := ts.DB.GetUserBalance(user.ID); after != before {
+		t.Errorf("Balance after void = %.2f, want %.2f as before the round", after, before)
+	}
+
+	// Voiding again changes nothing and reports the first void
+	again := &models.RoundVoid{GameID: round.GameID, Reason: "again", VoidedAt: crash.Add(2 * time.Minute)}
+	if err := ts.DB.VoidRound(again); !errors.Is(err, database.ErrRoundVoided) {
+		t.Fatalf("Second void returned %v, want ErrRoundVoided", err)
+	}
+	if again.Reason != "test" || len(again.Refunds) != 1 || again.Refunds[0] != void.Refunds[0] {
+		t.Errorf("Second void = %+v, want the first void %+v", again, void)
+	}
+	if after, _ := ts.DB.GetUserBalance(user.ID); after != before {
+		t.Errorf("Balance after second void = %.2f, want %.2f", after, before)
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"crash-game/internal/database"
+	"crash-game/internal/models"
+	"crash-game/internal/wallet"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingStore records the order changes are applied in, taking delay for
+// each.
+type recordingStore struct {
+	delay   time.Duration
+	mu      sync.Mutex
+	applied []string
+}
+
+func (s *recordingStore) PlaceBet(gameID string, serverSeedID int, bet *models.PlayerHistory) error {
+	time.Sleep(s.delay)
+	s.record(fmt.Sprintf("bet %s %.0f", bet.UserID, bet.BetAmount))
+	bet.BetID = 1
+	return nil
+}
+
+func (s *recordingStore) CancelBet(bet models.PlayerHistory, at time.Time) error {
+	time.Sleep(s.delay)
+	s.record(fmt.Sprintf("cancel %s %.0f", bet.UserID, bet.BetAmount))
+	return nil
+}
+
+func (s *recordingStore) RecordCashout(cashout database.Cashout) error {
+	time.Sleep(s.delay)
+	s.record(fmt.Sprintf("cashout %s %.0f", cashout.UserID, cashout.WinAmount))
+	return nil
+}
+
+func (s *recordingStore) record(change string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.applied = append(s.applied, change)
+}
+
+func (s *recordingStore) changesOf(user string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var changes []string
+	for _, change := range s.applied {
+		var kind, who string
+		fmt.Sscan(change, &kind, &who)
+		if who == user {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func TestPipelineKeepsOrderOfEachUser(t *testing.T) {
+	store := &recordingStore{delay: time.Millisecond}
+	p := wallet.New(store, 4)
+
+	users := []string{"a", "b", "c", "d", "e"}
+	for i := 1; i <= 5; i++ {
+		for _, user := range users {
+			p.RecordCashout(database.Cashout{UserID: user, WinAmount: float64(i)})
+		}
+	}
+	p.Flush()
+
+	for _, user := range users {
+		got := fmt.Sprint(store.changesOf(user))
+		want := fmt.Sprintf("[cashout %[1]s 1 cashout %[1]s 2 cashout %[1]s 3 cashout %[1]s 4 cashout %[1]s 5]", user)
+		if got != want {
+			t.Errorf("Changes of %s = %s, want %s", user, got, want)
+		}
+	}
+}
+
+func TestPipelineQueuesCashoutsWithoutWaiting(t *testing.T) {
+	store := &recordingStore{delay: 50 * time.Millisecond}
+	p := wallet.New(store, 1)
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		p.RecordCashout(database.Cashout{UserID: "a", WinAmount: float64(i)})
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("Queueing 10 cashouts took %v, want no wait for the store", elapsed)
+	}
+
+	p.Flush()
+	if n := len(store.changesOf("a")); n != 10 {
+		t.Errorf("Flush returned with %d of 10 cashouts applied", n)
+	}
+}
+
+func TestPipelinePlaceBetWaitsForItsTurn(t *testing.T) {
+	store := &recordingStore{}
+	p := wallet.New(store, 2)
+
+	p.RecordCashout(database.Cashout{UserID: "a", WinAmount: 5})
+	bet := models.PlayerHistory{UserID: "a", BetAmount: 10}
+	if err := p.PlaceBet("game", 1, &bet); err != nil {
+		t.Fatalf("PlaceBet() = %v", err)
+	}
+	if bet.BetID != 1 {
+		t.Errorf("PlaceBet() left BetID %d, want the store's", bet.BetID)
+	}
+	if got := fmt.Sprint(store.changesOf("a")); got != "[cashout a 5 bet a 10]" {
+		t.Errorf("Changes of a = %s, want the cashout before the bet", got)
+	}
+}